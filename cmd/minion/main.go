@@ -38,7 +38,7 @@ func main() {
 
 	// Запускаем HTTP сервер
 	fmt.Println("🍌 BELLO! Запуск Minion HTTP API сервера...")
-	if err := server.StartServer(envConfig.HTTPPort); err != nil {
+	if err := server.StartServer(envConfig.HTTPPort, envConfig.CORSAllowOrigins); err != nil {
 		log.Fatalf("❌ Ошибка запуска сервера: %v", err)
 	}
 }
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksMinReload - не чаще, чем раз в это время, JWKS перечитывается из-за неизвестного kid
+const jwksMinReload = time.Minute
+
+// jsonWebKey - ключ из JWKS; поддерживаются RSA и EC (P-256, P-384, P-521)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS - набор публичных ключей провайдера из файла или по URL.
+// Ключи кешируются и перечитываются раз в refresh или при появлении неизвестного kid
+type JWKS struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+	triedAt  time.Time
+}
+
+// NewJWKS создает набор ключей; source - путь к файлу или http(s) URL
+func NewJWKS(source string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Load перечитывает ключи
+func (j *JWKS) Load(ctx context.Context) error {
+	j.mu.Lock()
+	j.triedAt = time.Now()
+	j.mu.Unlock()
+
+	return j.reload(ctx)
+}
+
+// Key возвращает ключ по kid; пустой kid подходит, если ключ в наборе один.
+// JWKS читается без блокировки, поэтому медленный провайдер не задерживает проверку других токенов
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if j.due(j.refresh) {
+		if err := j.reload(ctx); err != nil && !j.loaded() {
+			return nil, err
+		}
+	}
+
+	if key, ok := j.find(kid); ok {
+		return key, nil
+	}
+
+	// Провайдер мог выпустить новый ключ: перечитываем набор, но не чаще jwksMinReload
+	if j.due(0) {
+		if err := j.reload(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := j.find(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ключ %q не найден в JWKS", kid)
+}
+
+// due проверяет, пора ли перечитать набор: ключи старше maxAge, а прошлая попытка была раньше jwksMinReload.
+// Попытка сразу отмечается, поэтому JWKS читает только один из одновременных запросов
+func (j *JWKS) due(maxAge time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if time.Since(j.loadedAt) <= maxAge || time.Since(j.triedAt) <= jwksMinReload {
+		return false
+	}
+	j.triedAt = time.Now()
+	return true
+}
+
+// loaded проверяет, что ключи уже были загружены
+func (j *JWKS) loaded() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.keys != nil
+}
+
+// find ищет ключ в кеше
+func (j *JWKS) find(kid string) (crypto.PublicKey, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// reload читает JWKS и заменяет набор ключей; при ошибке остаются прежние ключи
+func (j *JWKS) reload(ctx context.Context) error {
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.keys = keys
+	j.loadedAt = time.Now()
+	return nil
+}
+
+// fetch читает и разбирает JWKS
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := j.read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки JWKS %s: %v", j.source, err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JWKS %s: %v", j.source, err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS %s, ключ %q: %v", j.source, jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("в JWKS %s нет ключей подписи RSA или EC", j.source)
+	}
+
+	return keys, nil
+}
+
+// read читает JWKS из файла или по URL
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		return os.ReadFile(j.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("неожиданный статус %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+}
+
+// publicKey собирает публичный ключ; nil для неподдерживаемых типов ключей
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный n: %v", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("некорректный e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("неподдерживаемая кривая %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный x: %v", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный y: %v", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("точка не лежит на кривой %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, nil
+}
+
+// decodeBigInt декодирует base64url число без знака
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("пустое значение")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
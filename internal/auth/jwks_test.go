@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer отдает текущий набор ключей; block задерживает ответ, пока канал не закрыт,
+// а started сообщает, что запрос дошел до сервера
+type jwksServer struct {
+	mu      sync.Mutex
+	signers []testSigner
+	status  int
+	block   chan struct{}
+	started chan struct{}
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	signers, status, block, started := s.signers, s.status, s.block, s.started
+	s.mu.Unlock()
+
+	if block != nil {
+		started <- struct{}{}
+		<-block
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	keys := make([]map[string]string, 0, len(signers))
+	for _, signer := range signers {
+		keys = append(keys, signer.jwk())
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+func (s *jwksServer) set(change func(s *jwksServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s)
+}
+
+// allowReload разрешает перечитать набор, как будто с прошлой попытки прошло больше jwksMinReload
+func allowReload(j *JWKS) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.triedAt = time.Now().Add(-2 * jwksMinReload)
+}
+
+func TestJWKSReloadsUnknownKid(t *testing.T) {
+	first, second := newRSASigner(t, "first"), newRSASigner(t, "second")
+	provider := &jwksServer{signers: []testSigner{first}}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL, time.Hour)
+	if err := jwks.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Провайдер выпустил новый ключ, но с прошлой загрузки прошло меньше jwksMinReload
+	provider.set(func(s *jwksServer) { s.signers = []testSigner{first, second} })
+	if _, err := jwks.Key(context.Background(), "second"); err == nil {
+		t.Fatal("ключ не должен перечитываться чаще jwksMinReload")
+	}
+
+	allowReload(jwks)
+	if _, err := jwks.Key(context.Background(), "second"); err != nil {
+		t.Fatalf("новый ключ должен найтись после перечитывания: %v", err)
+	}
+}
+
+func TestJWKSKeepsKeysWhenReloadFails(t *testing.T) {
+	signer := newRSASigner(t, "main")
+	provider := &jwksServer{signers: []testSigner{signer}}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL, time.Millisecond)
+	if err := jwks.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	provider.set(func(s *jwksServer) { s.status = http.StatusBadGateway })
+	time.Sleep(5 * time.Millisecond)
+	allowReload(jwks)
+
+	if _, err := jwks.Key(context.Background(), "main"); err != nil {
+		t.Fatalf("при ошибке провайдера должны остаться прежние ключи: %v", err)
+	}
+}
+
+func TestJWKSDoesNotBlockWhileFetching(t *testing.T) {
+	signer := newRSASigner(t, "main")
+	provider := &jwksServer{signers: []testSigner{signer}}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL, time.Hour)
+	if err := jwks.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Запрос с неизвестным kid зависает на чтении JWKS
+	block, started := make(chan struct{}), make(chan struct{}, 1)
+	provider.set(func(s *jwksServer) { s.block, s.started = block, started })
+	allowReload(jwks)
+
+	fetching := make(chan struct{})
+	go func() {
+		defer close(fetching)
+		_, _ = jwks.Key(context.Background(), "unknown")
+	}()
+
+	<-started
+
+	found := make(chan error, 1)
+	go func() {
+		_, err := jwks.Key(context.Background(), "main")
+		found <- err
+	}()
+
+	select {
+	case err := <-found:
+		if err != nil {
+			t.Errorf("известный ключ: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("проверка известного ключа ждет чтения JWKS")
+	}
+
+	close(block)
+	<-fetching
+}
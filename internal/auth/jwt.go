@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// MethodJWT - клиент аутентифицирован JWT провайдера
+const MethodJWT = "jwt"
+
+// jwtLeeway - допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = time.Minute
+
+// jwtAlgorithm - хеш алгоритма подписи и, для ES*, кривая, которой он определен (RFC 7518)
+type jwtAlgorithm struct {
+	hash  crypto.Hash
+	curve string // пусто для RS*
+}
+
+// jwtAlgorithms - поддерживаемые алгоритмы подписи
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, curve: "P-256"},
+	"ES384": {hash: crypto.SHA384, curve: "P-384"},
+	"ES512": {hash: crypto.SHA512, curve: "P-521"},
+}
+
+// JWTConfig - требования к токенам и сопоставление ролей
+type JWTConfig struct {
+	Issuer        string
+	Audience      string
+	IdentityClaim string            // claim с именем клиента для логов, обычно sub
+	RolesClaim    string            // claim с ролями, можно вложенный: realm_access.roles
+	RoleMap       map[string]string // значение claim -> роль minion; роли minion подходят и без сопоставления
+}
+
+// Verifier проверяет JWT по ключам JWKS
+type Verifier struct {
+	jwks   *JWKS
+	config JWTConfig
+}
+
+// NewVerifier создает проверку JWT
+func NewVerifier(jwks *JWKS, config JWTConfig) *Verifier {
+	return &Verifier{jwks: jwks, config: config}
+}
+
+// LooksLikeJWT отличает JWT (три части через точку) от статического ключа
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify проверяет подпись, iss, aud, exp и nbf и возвращает клиента с наибольшей из его ролей
+func (v *Verifier) Verify(ctx context.Context, token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, errors.New("некорректный JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, fmt.Errorf("некорректный заголовок JWT: %v", err)
+	}
+
+	key, err := v.jwks.Key(ctx, header.Kid)
+	if err != nil {
+		return Identity{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, errors.New("некорректная подпись JWT")
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Identity{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, fmt.Errorf("некорректные claims JWT: %v", err)
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return Identity{}, err
+	}
+
+	name, _ := claim(claims, v.config.IdentityClaim).(string)
+	if name == "" {
+		return Identity{}, fmt.Errorf("в токене нет %s", v.config.IdentityClaim)
+	}
+
+	return Identity{Name: name, Method: MethodJWT, Role: v.role(claims)}, nil
+}
+
+// checkClaims проверяет издателя, аудиторию и срок действия
+func (v *Verifier) checkClaims(claims map[string]interface{}, now time.Time) error {
+	if issuer, _ := claims["iss"].(string); issuer != v.config.Issuer {
+		return fmt.Errorf("неверный издатель токена %q", issuer)
+	}
+
+	if !hasAudience(claims["aud"], v.config.Audience) {
+		return fmt.Errorf("токен выпущен не для %s", v.config.Audience)
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("в токене нет exp")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return errors.New("срок действия токена истек")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("токен еще не действует")
+	}
+
+	return nil
+}
+
+// role сопоставляет значения claim с ролями minion и выбирает наибольшую; пусто, если ролей нет
+func (v *Verifier) role(claims map[string]interface{}) string {
+	var values []string
+	switch value := claim(claims, v.config.RolesClaim).(type) {
+	case string:
+		values = strings.Fields(value)
+	case []interface{}:
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+	}
+
+	best := ""
+	for _, value := range values {
+		role, ok := v.config.RoleMap[value]
+		if !ok && ValidRole(value) {
+			role = value
+		}
+		if roleLevel(role) > roleLevel(best) {
+			best = role
+		}
+	}
+	return best
+}
+
+// claim достает значение по пути через точку: realm_access.roles
+func claim(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// hasAudience проверяет aud, который может быть строкой или массивом
+func hasAudience(aud interface{}, audience string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifySignature проверяет подпись алгоритмом alg; алгоритм должен соответствовать типу ключа,
+// а для ES* - еще и кривой ключа
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	algorithm, ok := jwtAlgorithms[alg]
+	if !ok {
+		return fmt.Errorf("неподдерживаемый алгоритм подписи %q", alg)
+	}
+	hasher := algorithm.hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm.curve == "" && rsa.VerifyPKCS1v15(pub, algorithm.hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if algorithm.curve == "" || pub.Curve.Params().Name != algorithm.curve {
+			return fmt.Errorf("алгоритм %s не подходит для ключа на кривой %s", alg, pub.Curve.Params().Name)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(pub, digest, r, s) {
+				return nil
+			}
+		}
+	}
+
+	return errors.New("неверная подпись токена")
+}
+
+// decodeSegment декодирует base64url JSON часть токена
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// ParseRoleMap разбирает сопоставление ролей "значение:роль,значение:роль"
+func ParseRoleMap(value string) (map[string]string, error) {
+	roles := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		index := strings.LastIndex(part, ":")
+		if index <= 0 {
+			return nil, fmt.Errorf("ожидается значение:роль, получено %s", part)
+		}
+		claimValue, role := strings.TrimSpace(part[:index]), strings.TrimSpace(part[index+1:])
+		if !ValidRole(role) {
+			return nil, fmt.Errorf("неизвестная роль %s, ожидается %s", role, strings.Join(Roles, ", "))
+		}
+		roles[claimValue] = role
+	}
+	return roles, nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSigner подписывает тестовые токены ключом из JWKS
+type testSigner struct {
+	kid string
+	key crypto.Signer
+}
+
+func newRSASigner(t *testing.T, kid string) testSigner {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("ошибка генерации RSA ключа: %v", err)
+	}
+	return testSigner{kid: kid, key: key}
+}
+
+func newECSigner(t *testing.T, kid string, curve elliptic.Curve) testSigner {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("ошибка генерации EC ключа: %v", err)
+	}
+	return testSigner{kid: kid, key: key}
+}
+
+// jwk возвращает публичный ключ в формате JWKS
+func (s testSigner) jwk() map[string]string {
+	encode := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": s.kid, "n": encode(key.N), "e": encode(big.NewInt(int64(key.E)))}
+	case *ecdsa.PrivateKey:
+		return map[string]string{"kty": "EC", "kid": s.kid, "crv": key.Curve.Params().Name, "x": encode(key.X), "y": encode(key.Y)}
+	}
+	return nil
+}
+
+// sign выпускает токен с алгоритмом alg в заголовке
+func (s testSigner) sign(t *testing.T, alg string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := crypto.SHA256
+	if algorithm, ok := jwtAlgorithms[alg]; ok {
+		hash = algorithm.hash
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest); err != nil {
+			t.Fatalf("ошибка подписи: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, sig, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			t.Fatalf("ошибка подписи: %v", err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		sig.FillBytes(signature[size:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writeJWKS сохраняет публичные ключи во временный файл и возвращает путь к нему
+func writeJWKS(t *testing.T, signers ...testSigner) string {
+	keys := make([]map[string]string, 0, len(signers))
+	for _, signer := range signers {
+		keys = append(keys, signer.jwk())
+	}
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("ошибка записи JWKS: %v", err)
+	}
+	return path
+}
+
+func TestVerifierVerify(t *testing.T) {
+	rsaSigner := newRSASigner(t, "rsa")
+	p256 := newECSigner(t, "p256", elliptic.P256())
+	p384 := newECSigner(t, "p384", elliptic.P384())
+	p521 := newECSigner(t, "p521", elliptic.P521())
+	unknown := newRSASigner(t, "unknown")
+
+	jwks := NewJWKS(writeJWKS(t, rsaSigner, p256, p384, p521), time.Hour)
+	if err := jwks.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	verifier := NewVerifier(jwks, JWTConfig{
+		Issuer:        "https://auth.example.com",
+		Audience:      "minion",
+		IdentityClaim: "sub",
+		RolesClaim:    "realm_access.roles",
+		RoleMap:       map[string]string{"minion-ops": RoleOperator},
+	})
+
+	now := time.Now()
+	claims := func(change func(claims map[string]interface{})) map[string]interface{} {
+		claims := map[string]interface{}{
+			"iss":          "https://auth.example.com",
+			"aud":          []string{"account", "minion"},
+			"sub":          "ci-bot",
+			"exp":          now.Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{"roles": []string{"minion-ops", "offline_access"}},
+		}
+		if change != nil {
+			change(claims)
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantRole string
+		wantErr  string
+	}{
+		{name: "RS256", token: rsaSigner.sign(t, "RS256", claims(nil)), wantRole: RoleOperator},
+		{name: "RS512", token: rsaSigner.sign(t, "RS512", claims(nil)), wantRole: RoleOperator},
+		{name: "ES256 на P-256", token: p256.sign(t, "ES256", claims(nil)), wantRole: RoleOperator},
+		{name: "ES384 на P-384", token: p384.sign(t, "ES384", claims(nil)), wantRole: RoleOperator},
+		{name: "ES512 на P-521", token: p521.sign(t, "ES512", claims(nil)), wantRole: RoleOperator},
+		{name: "роль minion без сопоставления", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["realm_access"] = map[string]interface{}{"roles": []string{"viewer", "admin"}}
+		})), wantRole: RoleAdmin},
+		{name: "без ролей", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			delete(c, "realm_access")
+		})), wantRole: ""},
+		{name: "aud строкой", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["aud"] = "minion"
+		})), wantRole: RoleOperator},
+
+		{name: "HS256", token: rsaSigner.sign(t, "HS256", claims(nil)), wantErr: "неподдерживаемый алгоритм"},
+		{name: "alg none", token: rsaSigner.sign(t, "none", claims(nil)), wantErr: "неподдерживаемый алгоритм"},
+		{name: "RS256 с EC ключом", token: p256.sign(t, "RS256", claims(nil)), wantErr: "не подходит"},
+		{name: "ES256 с RSA ключом", token: rsaSigner.sign(t, "ES256", claims(nil)), wantErr: "неверная подпись"},
+		{name: "ES384 на P-256", token: p256.sign(t, "ES384", claims(nil)), wantErr: "не подходит"},
+		{name: "ES256 на P-384", token: p384.sign(t, "ES256", claims(nil)), wantErr: "не подходит"},
+		{name: "ES512 на P-384", token: p384.sign(t, "ES512", claims(nil)), wantErr: "не подходит"},
+		{name: "истек", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["exp"] = now.Add(-2 * jwtLeeway).Unix()
+		})), wantErr: "истек"},
+		{name: "без exp", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), wantErr: "нет exp"},
+		{name: "еще не действует", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["nbf"] = now.Add(2 * jwtLeeway).Unix()
+		})), wantErr: "еще не действует"},
+		{name: "чужая аудитория", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["aud"] = "other"
+		})), wantErr: "не для minion"},
+		{name: "чужой издатель", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})), wantErr: "неверный издатель"},
+		{name: "без sub", token: rsaSigner.sign(t, "RS256", claims(func(c map[string]interface{}) {
+			delete(c, "sub")
+		})), wantErr: "нет sub"},
+		{name: "неизвестный kid", token: unknown.sign(t, "RS256", claims(nil)), wantErr: "не найден"},
+		{name: "подмененные claims", token: tamper(rsaSigner.sign(t, "RS256", claims(nil)), claims(func(c map[string]interface{}) {
+			c["sub"] = "admin"
+		})), wantErr: "неверная подпись"},
+		{name: "подпись другого ключа", token: withKid(unknown.sign(t, "RS256", claims(nil)), "rsa"), wantErr: "неверная подпись"},
+		{name: "не JWT", token: "a.b", wantErr: "некорректный JWT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() = %v, ожидалась ошибка с %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify(): %v", err)
+			}
+			if identity.Name != "ci-bot" || identity.Method != MethodJWT || identity.Role != tt.wantRole {
+				t.Errorf("Verify() = %+v, ожидались ci-bot, %s, роль %q", identity, MethodJWT, tt.wantRole)
+			}
+		})
+	}
+}
+
+// tamper заменяет claims токена, сохраняя заголовок и подпись
+func tamper(token string, claims map[string]interface{}) string {
+	parts := strings.Split(token, ".")
+	payload, _ := json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
+
+// withKid заменяет kid в заголовке токена, сохраняя claims и подпись
+func withKid(token, kid string) string {
+	parts := strings.Split(token, ".")
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	parts[0] = base64.RawURLEncoding.EncodeToString(header)
+	return strings.Join(parts, ".")
+}
+
+func TestParseRoleMap(t *testing.T) {
+	roles, err := ParseRoleMap("minion-ops:operator, group:/admins:admin,,")
+	if err != nil {
+		t.Fatalf("ParseRoleMap: %v", err)
+	}
+	if roles["minion-ops"] != RoleOperator || roles["group:/admins"] != RoleAdmin || len(roles) != 2 {
+		t.Errorf("ParseRoleMap() = %v", roles)
+	}
+
+	for _, value := range []string{"minion-ops", ":admin", "minion-ops:root"} {
+		if _, err := ParseRoleMap(value); err == nil {
+			t.Errorf("ParseRoleMap(%q): ожидалась ошибка", value)
+		}
+	}
+}
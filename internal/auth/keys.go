@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Способы аутентификации
+const (
+	MethodAPIKey = "api_key"
+)
+
+// Identity - аутентифицированный клиент API
+type Identity struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Role   string `json:"role"`
+}
+
+// String описывает клиента для логов и истории: api_key:admin-panel
+func (i Identity) String() string {
+	return i.Method + ":" + i.Name
+}
+
+// HashKey возвращает hex SHA-256 ключа; в конфигурации хранятся только хеши
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyStore проверяет статические API ключи по их хешам
+type KeyStore struct {
+	identities map[string]Identity // хеш ключа -> клиент
+}
+
+// NewKeyStore создает пустое хранилище ключей
+func NewKeyStore() *KeyStore {
+	return &KeyStore{identities: map[string]Identity{}}
+}
+
+// Add добавляет ключи в формате "имя:роль:sha256hex" через запятую; без роли ключ получает роль viewer
+func (s *KeyStore) Add(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Split(part, ":")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		var name, role, hash string
+		switch len(fields) {
+		case 2:
+			name, role, hash = fields[0], RoleViewer, fields[1]
+		case 3:
+			name, role, hash = fields[0], fields[1], fields[2]
+		default:
+			return fmt.Errorf("ключ должен быть в формате имя:роль:sha256")
+		}
+		hash = strings.ToLower(hash)
+
+		if name == "" {
+			return fmt.Errorf("ключ без имени")
+		}
+		if !ValidRole(role) {
+			return fmt.Errorf("ключ %s: неизвестная роль %s, ожидается %s", name, role, strings.Join(Roles, ", "))
+		}
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("ключ %s: ожидается hex SHA-256 из 64 символов", name)
+		}
+
+		s.identities[hash] = Identity{Name: name, Method: MethodAPIKey, Role: role}
+	}
+	return nil
+}
+
+// Len возвращает количество ключей
+func (s *KeyStore) Len() int {
+	return len(s.identities)
+}
+
+// Lookup находит клиента по предъявленному ключу
+func (s *KeyStore) Lookup(key string) (Identity, bool) {
+	identity, ok := s.identities[HashKey(key)]
+	return identity, ok
+}
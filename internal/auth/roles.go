@@ -0,0 +1,31 @@
+package auth
+
+// Роли клиентов API; каждая следующая включает права предыдущей
+const (
+	RoleViewer   = "viewer"   // конфигурация, отчеты, история, задачи, расписания
+	RoleOperator = "operator" // + обновление меню и отмена задач
+	RoleAdmin    = "admin"    // + продление ключей и управление расписаниями
+)
+
+// Roles - все роли по возрастанию прав
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// ValidRole проверяет, что роль существует
+func ValidRole(role string) bool {
+	return roleLevel(role) > 0
+}
+
+// HasRole проверяет, что у клиента есть права роли role
+func (i Identity) HasRole(role string) bool {
+	return roleLevel(i.Role) >= roleLevel(role)
+}
+
+// roleLevel возвращает уровень прав роли; 0 для неизвестной роли
+func roleLevel(role string) int {
+	for i, known := range Roles {
+		if role == known {
+			return i + 1
+		}
+	}
+	return 0
+}
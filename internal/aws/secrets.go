@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -37,13 +38,13 @@ func NewSecretsManager(region string) (*SecretsManager, error) {
 }
 
 // GetDatabaseCredentials получает данные для подключения к базе данных
-func (sm *SecretsManager) GetDatabaseCredentials(secretName string) (*models.DatabaseCredentials, error) {
+func (sm *SecretsManager) GetDatabaseCredentials(ctx context.Context, secretName string) (*models.DatabaseCredentials, error) {
 	// Получаем секрет
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(secretName),
 	}
 
-	result, err := sm.client.GetSecretValue(input)
+	result, err := sm.client.GetSecretValueWithContext(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения секрета %s: %v", secretName, err)
 	}
@@ -58,12 +59,12 @@ func (sm *SecretsManager) GetDatabaseCredentials(secretName string) (*models.Dat
 }
 
 // GetSecretValue получает произвольное значение секрета
-func (sm *SecretsManager) GetSecretValue(secretName string) (string, error) {
+func (sm *SecretsManager) GetSecretValue(ctx context.Context, secretName string) (string, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(secretName),
 	}
 
-	result, err := sm.client.GetSecretValue(input)
+	result, err := sm.client.GetSecretValueWithContext(ctx, input)
 	if err != nil {
 		return "", fmt.Errorf("ошибка получения секрета %s: %v", secretName, err)
 	}
@@ -74,3 +75,20 @@ func (sm *SecretsManager) GetSecretValue(secretName string) (string, error) {
 
 	return *result.SecretString, nil
 }
+
+// GetAPIKeys получает хеши API ключей minion из поля minion_api_keys секрета; пустая строка, если поля нет
+func (sm *SecretsManager) GetAPIKeys(ctx context.Context, secretName string) (string, error) {
+	value, err := sm.GetSecretValue(ctx, secretName)
+	if err != nil {
+		return "", err
+	}
+
+	var secret struct {
+		APIKeys string `json:"minion_api_keys"`
+	}
+	if err := json.Unmarshal([]byte(value), &secret); err != nil {
+		return "", fmt.Errorf("ошибка парсинга секрета %s: %v", secretName, err)
+	}
+
+	return secret.APIKeys, nil
+}
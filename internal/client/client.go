@@ -2,132 +2,300 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"minion/internal/metrics"
 	"minion/internal/models"
 )
 
-// IikoClient - HTTP клиент для работы с iiko API
+const loginPath = "/api/auth/login"
+
+// requestDuration - длительность HTTP попыток к iiko по эндпоинту и статусу ответа
+var requestDuration = metrics.Default.NewHistogram("minion_iiko_request_duration_seconds",
+	"Длительность запросов к iiko, каждая попытка отдельно", metrics.DefaultBuckets, "endpoint", "status")
+
+const browserUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36"
+
+// IikoClient - HTTP клиент для работы с iiko API.
+// Клиент сам управляет сессией: она кешируется по домену и логину и переиспользуется между операциями
 type IikoClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL     string
+	login       string
+	password    string
+	httpClient  *http.Client
+	session     *session
+	retryPolicy RetryPolicy
+
+	// Счетчики HTTP попыток и повторов для отчета по ресторану
+	attempts atomic.Int64
+	retries  atomic.Int64
 }
 
 // NewIikoClient создает новый экземпляр клиента
-func NewIikoClient(baseURL string) *IikoClient {
+func NewIikoClient(baseURL, login, password string) *IikoClient {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
 	return &IikoClient{
-		baseURL:    strings.TrimSuffix(baseURL, "/"),
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:  baseURL,
+		login:    login,
+		password: password,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			// Редиректы не выполняем: редирект на страницу логина означает истекшую сессию
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		session:     sessions.get(baseURL, login),
+		retryPolicy: DefaultRetryPolicy(),
 	}
 }
 
-// Login выполняет авторизацию и возвращает PHPSESSID
-func (c *IikoClient) Login(login, password string) (string, error) {
-	loginData := models.LoginRequest{Login: login, Password: password}
+// SetRetryPolicy задает политику повторных попыток
+func (c *IikoClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// Attempts возвращает количество HTTP запросов к iiko, включая повторы
+func (c *IikoClient) Attempts() int {
+	return int(c.attempts.Load())
+}
+
+// Retries возвращает количество повторных попыток
+func (c *IikoClient) Retries() int {
+	return int(c.retries.Load())
+}
+
+// Login авторизуется в iikoWeb, если для домена и логина еще нет активной сессии
+func (c *IikoClient) Login(ctx context.Context) error {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.generation > 0 {
+		return nil
+	}
+	return c.loginLocked(ctx)
+}
+
+// relogin повторно авторизуется, если сессию еще не обновил другой запрос
+func (c *IikoClient) relogin(ctx context.Context, staleGeneration int) error {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.session.generation != staleGeneration {
+		return nil
+	}
+	return c.loginLocked(ctx)
+}
+
+// loginLocked выполняет авторизацию и сохраняет новую сессию; вызывается под блокировкой сессии
+func (c *IikoClient) loginLocked(ctx context.Context) error {
+	loginData := models.LoginRequest{Login: c.login, Password: c.password}
 	jsonData, _ := json.Marshal(loginData)
 
-	req, err := http.NewRequest("POST", c.baseURL+"/api/auth/login", bytes.NewBuffer(jsonData))
+	loginURL, err := url.Parse(c.baseURL + loginPath)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	// Повторная авторизация безопасна, поэтому логин всегда повторяется по политике
+	resp, err := c.withRetry(ctx, true, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", loginURL.String(), bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := c.httpClient.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		startedAt := time.Now()
+		resp, err := c.httpClient.Do(req)
+		observeRequest(loginPath, startedAt, resp, err)
+		if err != nil {
+			return nil, networkError(loginPath, err)
+		}
+		return resp, nil
+	})
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("авторизация не удалась, статус: %d", resp.StatusCode)
+		return statusError(loginPath, resp)
 	}
 
-	for _, cookie := range resp.Cookies() {
+	jar := newCookieJar()
+	jar.SetCookies(loginURL, resp.Cookies())
+
+	for _, cookie := range jar.Cookies(loginURL) {
 		if cookie.Name == "PHPSESSID" {
-			return cookie.Value, nil
+			c.session.jar = jar
+			c.session.generation++
+			return nil
 		}
 	}
 
-	return "", fmt.Errorf("PHPSESSID не найден")
+	// iiko может ответить 200 без сессии при неверных учетных данных
+	return &AuthError{RequestError: RequestError{
+		Endpoint:   loginPath,
+		StatusCode: resp.StatusCode,
+		Body:       bodySnippet(resp),
+	}}
 }
 
-// GetApiLogins получает список API логинов
-func (c *IikoClient) GetApiLogins(sessionID string) (*models.ApiLoginsResponse, error) {
-	req, err := http.NewRequest("GET", c.baseURL+"/api/integration-management/api-logins/get-all", nil)
-	if err != nil {
+// send выполняет запрос в рамках сессии и один раз переавторизуется, если сессия истекла.
+// Идемпотентные запросы повторяются по политике, изменяющие - только если это явно разрешено.
+// Тело ответа закрывает вызывающий код
+func (c *IikoClient) send(ctx context.Context, method, path string, payload interface{}, headers map[string]string, idempotent bool) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = jsonData
+	}
+
+	if err := c.Login(ctx); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "ru_RU")
-	req.Header.Set("Cookie", "PHPSESSID="+sessionID)
-	req.Header.Set("Origin", c.baseURL)
-	req.Header.Set("Referer", c.baseURL+"/integration-management/index.html")
+	for attempt := 0; ; attempt++ {
+		jar, generation := c.session.current()
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+		resp, err := c.withRetry(ctx, idempotent || c.retryPolicy.RetryMutations, func() (*http.Response, error) {
+			return c.do(ctx, method, path, body, headers, jar)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt == 0 && isSessionExpired(resp) {
+			resp.Body.Close()
+			if err := c.relogin(ctx, generation); err != nil {
+				return nil, fmt.Errorf("повторная авторизация не удалась: %w", err)
+			}
+			continue
+		}
+
+		return resp, nil
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка получения логинов, статус: %d", resp.StatusCode)
+// do выполняет один HTTP запрос с cookie из jar сессии
+func (c *IikoClient) do(ctx context.Context, method, path string, body []byte, headers map[string]string, jar http.CookieJar) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	var response models.ApiLoginsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for _, cookie := range jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
 	}
 
-	return &response, nil
+	startedAt := time.Now()
+	resp, err := c.httpClient.Do(req)
+	observeRequest(path, startedAt, resp, err)
+	if err != nil {
+		return nil, networkError(path, err)
+	}
+
+	jar.SetCookies(req.URL, resp.Cookies())
+
+	return resp, nil
 }
 
-// GetApiLoginDetail получает детальную информацию об API логине
-func (c *IikoClient) GetApiLoginDetail(sessionID string, apiLoginID string) (*models.ApiLoginDetailResponse, error) {
-	requestData := models.ApiLoginDetailRequest{ApiLoginID: apiLoginID}
-	jsonData, _ := json.Marshal(requestData)
+// observeRequest записывает длительность попытки; без ответа статус - error
+func observeRequest(path string, startedAt time.Time, resp *http.Response, err error) {
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestDuration.Observe(time.Since(startedAt).Seconds(), endpointLabel(path), status)
+}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/api/integration-management/api-logins/get", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+// endpointLabel заменяет числовые сегменты пути на :id, чтобы у метрики было конечное число серий
+func endpointLabel(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = ":id"
+		}
 	}
+	return strings.Join(segments, "/")
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "ru_RU")
-	req.Header.Set("Cookie", "PHPSESSID="+sessionID)
-	req.Header.Set("Origin", c.baseURL)
-	req.Header.Set("Referer", c.baseURL+"/integration-management/index.html")
+// integrationHeaders возвращает заголовки для раздела integration-management
+func (c *IikoClient) integrationHeaders() map[string]string {
+	return map[string]string{
+		"Accept":          "application/json, text/plain, */*",
+		"Accept-Language": "ru_RU",
+		"Origin":          c.baseURL,
+		"Referer":         c.baseURL + "/integration-management/index.html",
+	}
+}
 
-	resp, err := c.httpClient.Do(req)
+// externalMenuHeaders возвращает заголовки для раздела external-menu
+func (c *IikoClient) externalMenuHeaders() map[string]string {
+	return map[string]string{
+		"Accept":          "application/json, text/plain, */*",
+		"Accept-Language": "ru_RU",
+		"Referer":         c.baseURL + "/external-menu/index.html",
+		"User-Agent":      browserUserAgent,
+	}
+}
+
+// GetApiLogins получает список API логинов
+func (c *IikoClient) GetApiLogins(ctx context.Context) (*models.ApiLoginsResponse, error) {
+	path := "/api/integration-management/api-logins/get-all"
+	resp, err := c.send(ctx, "GET", path, nil, c.integrationHeaders(), true)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка получения деталей, статус: %d", resp.StatusCode)
+	var response models.ApiLoginsResponse
+	if err := readJSON(path, resp, &response); err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return &response, nil
+}
+
+// GetApiLoginDetail получает детальную информацию об API логине
+func (c *IikoClient) GetApiLoginDetail(ctx context.Context, apiLoginID string) (*models.ApiLoginDetailResponse, error) {
+	requestData := models.ApiLoginDetailRequest{ApiLoginID: apiLoginID}
+
+	path := "/api/integration-management/api-logins/get"
+	resp, err := c.send(ctx, "POST", path, requestData, c.integrationHeaders(), true)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	var response models.ApiLoginDetailResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := readJSON(path, resp, &response); err != nil {
 		return nil, err
 	}
 
@@ -135,64 +303,32 @@ func (c *IikoClient) GetApiLoginDetail(sessionID string, apiLoginID string) (*mo
 }
 
 // SaveApiLoginDetail сохраняет обновленный API логин
-func (c *IikoClient) SaveApiLoginDetail(sessionID string, apiLoginDetail models.ApiLoginDetail) error {
-	jsonData, _ := json.Marshal(apiLoginDetail)
-
-	req, err := http.NewRequest("POST", c.baseURL+"/api/integration-management/save-api-login", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "ru_RU")
-	req.Header.Set("Cookie", "PHPSESSID="+sessionID)
-	req.Header.Set("Origin", c.baseURL)
-	req.Header.Set("Referer", c.baseURL+"/integration-management/index.html")
-
-	resp, err := c.httpClient.Do(req)
+func (c *IikoClient) SaveApiLoginDetail(ctx context.Context, apiLoginDetail models.ApiLoginDetail) error {
+	path := "/api/integration-management/save-api-login"
+	resp, err := c.send(ctx, "POST", path, apiLoginDetail, c.integrationHeaders(), false)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ошибка сохранения, статус: %d", resp.StatusCode)
+		return statusError(path, resp)
 	}
 
 	return nil
 }
 
 // GetExternalMenus получает список внешних меню
-func (c *IikoClient) GetExternalMenus(sessionID string) (*models.ExternalMenuResponse, error) {
-	req, err := http.NewRequest("GET", c.baseURL+"/api/external-menu", nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "ru_RU")
-	req.Header.Set("Cookie", "PHPSESSID="+sessionID)
-	req.Header.Set("Referer", c.baseURL+"/external-menu/index.html")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36")
-
-	resp, err := c.httpClient.Do(req)
+func (c *IikoClient) GetExternalMenus(ctx context.Context) (*models.ExternalMenuResponse, error) {
+	path := "/api/external-menu"
+	resp, err := c.send(ctx, "GET", path, nil, c.externalMenuHeaders(), true)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ошибка получения меню, статус: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var response models.ExternalMenuResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := readJSON(path, resp, &response); err != nil {
 		return nil, err
 	}
 
@@ -200,7 +336,7 @@ func (c *IikoClient) GetExternalMenus(sessionID string) (*models.ExternalMenuRes
 }
 
 // RefreshExternalMenu обновляет внешнее меню
-func (c *IikoClient) RefreshExternalMenu(sessionID string, menuID int) error {
+func (c *IikoClient) RefreshExternalMenu(ctx context.Context, menuID int) error {
 	refreshData := models.RefreshMenuRequest{
 		RefreshNameAndDescription:       false,
 		RefreshPrice:                    true,
@@ -211,28 +347,33 @@ func (c *IikoClient) RefreshExternalMenu(sessionID string, menuID int) error {
 		RefreshCombos:                   true,
 	}
 
-	jsonData, _ := json.Marshal(refreshData)
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/external-menu/refresh-menu/%d", c.baseURL, menuID), bytes.NewBuffer(jsonData))
+	path := fmt.Sprintf("/api/external-menu/refresh-menu/%d", menuID)
+	resp, err := c.send(ctx, "POST", path, refreshData, c.externalMenuHeaders(), false)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "ru_RU")
-	req.Header.Set("Cookie", "PHPSESSID="+sessionID)
-	req.Header.Set("Referer", c.baseURL+"/external-menu/index.html")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36")
+	if resp.StatusCode != http.StatusOK {
+		return statusError(path, resp)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	return nil
+}
+
+// readJSON проверяет статус ответа и разбирает JSON тело в out
+func readJSON(path string, resp *http.Response, out interface{}) error {
+	if resp.StatusCode != http.StatusOK {
+		return statusError(path, resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return networkError(path, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ошибка обновления меню, статус: %d", resp.StatusCode)
+	if err := json.Unmarshal(body, out); err != nil {
+		return malformedError(path, resp, body, err)
 	}
 
 	return nil
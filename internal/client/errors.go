@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Машиночитаемые коды ошибок для результатов операций
+const (
+	ErrorCodeAuth              = "auth_failed"
+	ErrorCodeNotFound          = "not_found"
+	ErrorCodeRateLimited       = "rate_limited"
+	ErrorCodeServer            = "server_error"
+	ErrorCodeUnexpectedStatus  = "unexpected_status"
+	ErrorCodeDNS               = "dns_error"
+	ErrorCodeTLS               = "tls_error"
+	ErrorCodeTimeout           = "timeout"
+	ErrorCodeConnection        = "connection_error"
+	ErrorCodeMalformedResponse = "malformed_response"
+	ErrorCodeCancelled         = "cancelled"
+	ErrorCodeUnknown           = "unknown"
+)
+
+// maxBodySnippet - сколько символов тела ответа сохраняем в ошибке
+const maxBodySnippet = 200
+
+// RequestError содержит общие сведения о неудачном запросе к iiko
+type RequestError struct {
+	Endpoint   string // путь запроса, например /api/external-menu
+	StatusCode int    // HTTP статус, 0 если ответа не было
+	Body       string // начало тела ответа
+}
+
+// describe формирует текст ошибки с эндпоинтом, статусом и началом тела ответа
+func (e RequestError) describe(message string) string {
+	text := fmt.Sprintf("%s: %s", message, e.Endpoint)
+	if e.StatusCode != 0 {
+		text += fmt.Sprintf(", статус: %d", e.StatusCode)
+	}
+	if e.Body != "" {
+		text += fmt.Sprintf(", ответ: %s", e.Body)
+	}
+	return text
+}
+
+// AuthError - iiko отклонил учетные данные или сессию (401/403, редирект на логин)
+type AuthError struct {
+	RequestError
+}
+
+func (e *AuthError) Error() string {
+	return e.describe("ошибка авторизации в iiko")
+}
+
+// NotFoundError - запрошенный ресурс не найден (404)
+type NotFoundError struct {
+	RequestError
+}
+
+func (e *NotFoundError) Error() string {
+	return e.describe("ресурс iiko не найден")
+}
+
+// RateLimitError - iiko ограничил частоту запросов (429)
+type RateLimitError struct {
+	RequestError
+	RetryAfter time.Duration // значение Retry-After, если iiko его прислал
+}
+
+func (e *RateLimitError) Error() string {
+	return e.describe("iiko ограничил частоту запросов")
+}
+
+// ServerError - ошибка на стороне iiko (5xx)
+type ServerError struct {
+	RequestError
+}
+
+func (e *ServerError) Error() string {
+	return e.describe("ошибка сервера iiko")
+}
+
+// StatusError - прочие неожиданные HTTP статусы
+type StatusError struct {
+	RequestError
+}
+
+func (e *StatusError) Error() string {
+	return e.describe("неожиданный ответ iiko")
+}
+
+// NetworkError - запрос не дошел до iiko или ответ не был получен (DNS, TLS, таймаут, соединение)
+type NetworkError struct {
+	RequestError
+	Code string // один из ErrorCodeDNS, ErrorCodeTLS, ErrorCodeTimeout, ErrorCodeConnection
+	Err  error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("%s: %v", e.describe("сетевая ошибка при обращении к iiko"), e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// MalformedResponseError - iiko ответил, но тело не удалось разобрать
+type MalformedResponseError struct {
+	RequestError
+	Err error
+}
+
+func (e *MalformedResponseError) Error() string {
+	return fmt.Sprintf("%s: %v", e.describe("некорректный ответ iiko"), e.Err)
+}
+
+func (e *MalformedResponseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode возвращает машиночитаемый код ошибки для результата операции
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var (
+		authErr      *AuthError
+		notFoundErr  *NotFoundError
+		rateLimitErr *RateLimitError
+		serverErr    *ServerError
+		statusErr    *StatusError
+		networkErr   *NetworkError
+		malformedErr *MalformedResponseError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	case errors.As(err, &authErr):
+		return ErrorCodeAuth
+	case errors.As(err, &notFoundErr):
+		return ErrorCodeNotFound
+	case errors.As(err, &rateLimitErr):
+		return ErrorCodeRateLimited
+	case errors.As(err, &serverErr):
+		return ErrorCodeServer
+	case errors.As(err, &statusErr):
+		return ErrorCodeUnexpectedStatus
+	case errors.As(err, &networkErr):
+		return networkErr.Code
+	case errors.As(err, &malformedErr):
+		return ErrorCodeMalformedResponse
+	}
+
+	return ErrorCodeUnknown
+}
+
+// statusError формирует типизированную ошибку по неуспешному ответу; тело закрывает вызывающий код
+func statusError(endpoint string, resp *http.Response) error {
+	base := RequestError{
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Body:       bodySnippet(resp),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || isSessionExpired(resp):
+		return &AuthError{RequestError: base}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{RequestError: base}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		after, _ := retryAfter(resp)
+		return &RateLimitError{RequestError: base, RetryAfter: after}
+	case resp.StatusCode >= 500:
+		return &ServerError{RequestError: base}
+	}
+
+	return &StatusError{RequestError: base}
+}
+
+// networkError классифицирует ошибку транспорта
+func networkError(endpoint string, err error) error {
+	var (
+		dnsErr       *net.DNSError
+		certErr      *tls.CertificateVerificationError
+		unknownAuth  x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidCert  x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		netErr       net.Error
+		code         = ErrorCodeConnection
+		requestError = RequestError{Endpoint: endpoint}
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		code = ErrorCodeDNS
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr):
+		code = ErrorCodeTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		code = ErrorCodeTimeout
+	}
+
+	return &NetworkError{RequestError: requestError, Code: code, Err: err}
+}
+
+// malformedError формирует ошибку разбора ответа
+func malformedError(endpoint string, resp *http.Response, body []byte, err error) error {
+	return &MalformedResponseError{
+		RequestError: RequestError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Body:       truncate(string(body)),
+		},
+		Err: err,
+	}
+}
+
+// bodySnippet читает начало тела ответа для сообщения об ошибке
+func bodySnippet(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*maxBodySnippet))
+	return truncate(string(body))
+}
+
+// truncate обрезает строку до maxBodySnippet символов, не разрывая UTF-8
+func truncate(text string) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= maxBodySnippet {
+		return text
+	}
+	return string([]rune(text)[:maxBodySnippet]) + "…"
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy описывает повторные попытки запросов к iiko
+type RetryPolicy struct {
+	MaxAttempts    int           // общее количество попыток, включая первую
+	BaseDelay      time.Duration // задержка перед первым повтором, дальше удваивается
+	MaxDelay       time.Duration // верхняя граница задержки и допустимого Retry-After
+	Jitter         float64       // доля случайного разброса задержки, от 0 до 1
+	RetryOn        map[int]bool  // HTTP статусы, после которых запрос повторяется
+	RetryMutations bool          // повторять ли изменяющие запросы (сохранение логина, обновление меню)
+}
+
+// DefaultRetryPolicy возвращает политику повторов по умолчанию
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+		RetryOn: map[int]bool{
+			http.StatusTooManyRequests:     true,
+			http.StatusInternalServerError: true,
+			http.StatusBadGateway:          true,
+			http.StatusServiceUnavailable:  true,
+			http.StatusGatewayTimeout:      true,
+		},
+	}
+}
+
+// backoff вычисляет задержку перед повтором номер retry (начиная с 1) с учетом разброса
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+
+	return delay
+}
+
+// retryAfter разбирает заголовок Retry-After (секунды или HTTP дата)
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// withRetry выполняет attempt с повторами по политике клиента.
+// Если повторять нельзя, попытки закончились или ctx отменен, возвращается последний ответ или ошибка
+func (c *IikoClient) withRetry(ctx context.Context, retryable bool, attempt func() (*http.Response, error)) (*http.Response, error) {
+	policy := c.retryPolicy
+	maxAttempts := policy.MaxAttempts
+	if !retryable || maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for n := 1; ; n++ {
+		c.attempts.Add(1)
+		resp, err := attempt()
+
+		if n >= maxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			delay = policy.backoff(n)
+		case policy.RetryOn[resp.StatusCode]:
+			delay = policy.backoff(n)
+			if after, ok := retryAfter(resp); ok {
+				// iiko просит подождать дольше, чем мы готовы: отдаем ответ как есть
+				if after > policy.MaxDelay {
+					return resp, nil
+				}
+				delay = after
+			}
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		c.retries.Add(1)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext ждет delay или отмены ctx
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 3 * time.Second}
+
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{4, 3 * time.Second}, // 4s обрезается до MaxDelay
+		{10, 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) = %s, ожидалось %s", tt.retry, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(2)
+		if got < 1600*time.Millisecond || got > 2400*time.Millisecond {
+			t.Fatalf("backoff(2) = %s, ожидалось 2s ± 20%%", got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"нет заголовка", "", 0, false},
+		{"секунды", "3", 3 * time.Second, true},
+		{"ноль секунд", "0", 0, true},
+		{"отрицательное число", "-1", 0, false},
+		{"мусор", "soon", 0, false},
+		{"дата в прошлом", "Mon, 01 Jan 2001 00:00:00 GMT", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := retryAfter(resp)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter(%q) = %s, %t, ожидалось %s, %t", tt.header, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", time.Now().Add(30*time.Second).UTC().Format(http.TimeFormat))
+
+	got, ok := retryAfter(resp)
+	if !ok || got <= 28*time.Second || got > 30*time.Second {
+		t.Errorf("retryAfter(дата через 30s) = %s, %t", got, ok)
+	}
+}
+
+// response создает ответ с пустым телом и заголовками headers (имя, значение, ...)
+func response(status int, headers ...string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+	for i := 0; i+1 < len(headers); i += 2 {
+		resp.Header.Set(headers[i], headers[i+1])
+	}
+	return resp
+}
+
+func TestWithRetry(t *testing.T) {
+	errNetwork := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		retryable    bool
+		responses    []*http.Response
+		errs         []error
+		wantAttempts int
+		wantStatus   int // 0 - ожидается ошибка
+	}{
+		{
+			name:         "успех с первой попытки",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusOK)},
+			wantAttempts: 1,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "повтор после 503",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusServiceUnavailable), response(http.StatusOK)},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "повтор после сетевой ошибки",
+			retryable:    true,
+			responses:    []*http.Response{nil, response(http.StatusOK)},
+			errs:         []error{errNetwork, nil},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "попытки закончились",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusBadGateway), response(http.StatusBadGateway), response(http.StatusBadGateway)},
+			wantAttempts: 3,
+			wantStatus:   http.StatusBadGateway,
+		},
+		{
+			name:         "статус без повтора",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusNotFound)},
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name:         "изменяющий запрос не повторяется",
+			retryable:    false,
+			responses:    []*http.Response{response(http.StatusServiceUnavailable)},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:         "Retry-After в пределах MaxDelay",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusTooManyRequests, "Retry-After", "0"), response(http.StatusOK)},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name:         "Retry-After дольше MaxDelay",
+			retryable:    true,
+			responses:    []*http.Response{response(http.StatusTooManyRequests, "Retry-After", "120")},
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "сетевая ошибка на последней попытке",
+			retryable:    true,
+			responses:    []*http.Response{nil, nil, nil},
+			errs:         []error{errNetwork, errNetwork, errNetwork},
+			wantAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultRetryPolicy()
+			policy.BaseDelay = time.Millisecond
+			policy.MaxDelay = 5 * time.Millisecond
+
+			apiClient := NewIikoClient("https://example.iikoweb.ru", "login", "password")
+			apiClient.SetRetryPolicy(policy)
+
+			n := 0
+			resp, err := apiClient.withRetry(context.Background(), tt.retryable, func() (*http.Response, error) {
+				if n >= len(tt.responses) {
+					t.Fatalf("лишняя попытка %d", n+1)
+				}
+				resp := tt.responses[n]
+				var err error
+				if n < len(tt.errs) {
+					err = tt.errs[n]
+				}
+				n++
+				return resp, err
+			})
+
+			if apiClient.Attempts() != tt.wantAttempts || apiClient.Retries() != tt.wantAttempts-1 {
+				t.Errorf("попыток %d, повторов %d, ожидалось %d и %d", apiClient.Attempts(), apiClient.Retries(), tt.wantAttempts, tt.wantAttempts-1)
+			}
+			if tt.wantStatus == 0 {
+				if err == nil {
+					t.Errorf("ожидалась ошибка, получен статус %d", resp.StatusCode)
+				}
+				return
+			}
+			if err != nil || resp.StatusCode != tt.wantStatus {
+				t.Errorf("withRetry() = %v, %v, ожидался статус %d", resp, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsOnCancel(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = time.Hour
+
+	apiClient := NewIikoClient("https://example.iikoweb.ru", "login", "password")
+	apiClient.SetRetryPolicy(policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := apiClient.withRetry(ctx, true, func() (*http.Response, error) {
+		return response(http.StatusServiceUnavailable), nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ожидалась отмена ожидания повтора, получено %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// session - сессия iikoWeb для пары домен + логин, общая для всех клиентов и операций
+type session struct {
+	mu         sync.Mutex
+	jar        http.CookieJar
+	generation int // увеличивается при каждой успешной авторизации, 0 - сессии еще нет
+}
+
+// sessionStore хранит сессии iikoWeb в памяти процесса
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// sessions - кеш сессий, переживающий отдельные запросы и операции
+var sessions = &sessionStore{sessions: make(map[string]*session)}
+
+// get возвращает сессию для домена и логина, создавая ее при необходимости
+func (s *sessionStore) get(baseURL, login string) *session {
+	key := sessionKey(baseURL, login)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[key]
+	if !ok {
+		sess = &session{}
+		s.sessions[key] = sess
+	}
+	return sess
+}
+
+// current возвращает cookie jar и поколение текущей сессии
+func (s *session) current() (http.CookieJar, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.jar, s.generation
+}
+
+// sessionKey формирует ключ кеша сессий из домена и логина
+func sessionKey(baseURL, login string) string {
+	host := baseURL
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return strings.ToLower(host) + "|" + login
+}
+
+// newCookieJar создает пустой cookie jar для новой сессии
+func newCookieJar() http.CookieJar {
+	// cookiejar.New возвращает ошибку только при некорректных опциях
+	jar, _ := cookiejar.New(nil)
+	return jar
+}
+
+// isSessionExpired проверяет, что iiko отклонил запрос из-за истекшей сессии
+func isSessionExpired(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect:
+		return strings.Contains(strings.ToLower(resp.Header.Get("Location")), "login")
+	}
+	return false
+}
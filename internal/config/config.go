@@ -3,10 +3,16 @@ package config
 import (
 	"context"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"minion/internal/aws"
 	"minion/internal/database"
+	"minion/internal/keys"
 	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // EnvConfig содержит конфигурацию из переменных окружения
@@ -17,6 +23,82 @@ type EnvConfig struct {
 	// Настройки AWS Secrets Manager
 	AWSRegion     string // AWS_REGION
 	AWSSecretName string // AWS_SECRET_NAME
+
+	// Аутентификация API: статические ключи хранятся как "имя:роль:sha256" (роль можно опустить)
+	AuthEnabled      bool   // AUTH_ENABLED
+	APIKeys          string // API_KEYS, дополняются полем minion_api_keys секрета AWS
+	CORSAllowOrigins string // CORS_ALLOW_ORIGINS
+	AuditFile        string // AUDIT_FILE, JSON Lines копия журнала аудита; пусто - только MongoDB
+
+	// JWT провайдера; пустой JWT_JWKS - JWT не принимаются
+	JWTJWKS          string        // JWT_JWKS, путь к файлу или URL
+	JWTJWKSRefresh   time.Duration // JWT_JWKS_REFRESH
+	JWTIssuer        string        // JWT_ISSUER
+	JWTAudience      string        // JWT_AUDIENCE
+	JWTIdentityClaim string        // JWT_IDENTITY_CLAIM
+	JWTRolesClaim    string        // JWT_ROLES_CLAIM
+	JWTRoleMap       string        // JWT_ROLE_MAP, значение claim:роль через запятую
+
+	// Настройки параллельной обработки
+	Concurrency       int // MINION_CONCURRENCY
+	DomainConcurrency int // MINION_DOMAIN_CONCURRENCY
+
+	// Настройки повторных запросов к iiko
+	RetryMaxAttempts int           // IIKO_RETRY_MAX_ATTEMPTS
+	RetryBaseDelay   time.Duration // IIKO_RETRY_BASE_DELAY
+	RetryMaxDelay    time.Duration // IIKO_RETRY_MAX_DELAY
+	RetryMutations   bool          // IIKO_RETRY_MUTATIONS
+
+	// Максимальная длительность одного запуска операции
+	RunTimeout time.Duration // MINION_RUN_TIMEOUT
+
+	// Продление ключей по умолчанию, если в запросе не указано иное
+	KeyExtensionYears    int       // KEY_EXTENSION_YEARS
+	KeyExtensionMonths   int       // KEY_EXTENSION_MONTHS
+	KeyExtensionDays     int       // KEY_EXTENSION_DAYS
+	KeyMaxExpirationDate time.Time // KEY_MAX_EXPIRATION_DATE
+
+	// Политика продления ключей, может быть переопределена для ресторана
+	KeyThresholdDays int // KEY_EXTENSION_THRESHOLD_DAYS
+	KeyHorizonDays   int // KEY_EXTENSION_HORIZON_DAYS
+
+	// Встроенный планировщик: cron выражения операций, пустое - расписание выключено
+	ScheduleTimezone     string // SCHEDULE_TIMEZONE
+	ScheduleExtendKeys   string // SCHEDULE_EXTEND_KEYS
+	ScheduleRefreshMenus string // SCHEDULE_REFRESH_MENUS
+
+	// Время жизни блокировки операции; блокировка продлевается, пока операция выполняется
+	LeaseTTL time.Duration // MINION_LEASE_TTL
+
+	// Мониторинг сроков действия ключей
+	MonitorInterval   time.Duration // MONITOR_INTERVAL, 0 - мониторинг выключен
+	MonitorThresholds string        // MONITOR_THRESHOLDS, пороги в днях через запятую
+
+	// Оповещения
+	NotifyWebhooks    []WebhookConfig // NOTIFY_WEBHOOKS и NOTIFY_WEBHOOK_<ИМЯ>_*
+	NotifyMaxAttempts int             // NOTIFY_MAX_ATTEMPTS
+	NotifyTimeout     time.Duration   // NOTIFY_TIMEOUT
+
+	// Оповещения ресторанов в WhatsApp через HTTP шлюз; пустой URL - выключено
+	WhatsAppGatewayURL   string        // WHATSAPP_GATEWAY_URL
+	WhatsAppGatewayToken string        // WHATSAPP_GATEWAY_TOKEN
+	WhatsAppEvents       string        // WHATSAPP_EVENTS
+	WhatsAppChatLimit    int           // WHATSAPP_CHAT_LIMIT, сообщений в один чат за окно
+	WhatsAppChatWindow   time.Duration // WHATSAPP_CHAT_WINDOW
+
+	// Telegram бот операторов; пустой токен - бот выключен
+	TelegramBotToken string // TELEGRAM_BOT_TOKEN
+	TelegramAPIURL   string // TELEGRAM_API_URL
+	TelegramChatIDs  string // TELEGRAM_CHAT_IDS, разрешенные чаты через запятую
+	TelegramEvents   string // TELEGRAM_EVENTS
+}
+
+// WebhookConfig - настройки одного webhook для оповещений
+type WebhookConfig struct {
+	Name   string
+	URL    string // NOTIFY_WEBHOOK_<ИМЯ>_URL
+	Secret string // NOTIFY_WEBHOOK_<ИМЯ>_SECRET, ключ подписи HMAC
+	Events string // NOTIFY_WEBHOOK_<ИМЯ>_EVENTS, события через запятую; пустое - все
 }
 
 // LoadEnvConfig загружает конфигурацию из переменных окружения
@@ -28,46 +110,129 @@ func LoadEnvConfig() *EnvConfig {
 		// Настройки AWS Secrets Manager
 		AWSRegion:     getEnvWithDefault("AWS_REGION", "eu-west-1"),
 		AWSSecretName: getEnvWithDefault("AWS_SECRET_NAME", "ProdEnvs"),
+
+		// Аутентификация API
+		AuthEnabled:      getEnvBoolWithDefault("AUTH_ENABLED", true),
+		APIKeys:          os.Getenv("API_KEYS"),
+		CORSAllowOrigins: getEnvWithDefault("CORS_ALLOW_ORIGINS", "*"),
+		AuditFile:        os.Getenv("AUDIT_FILE"),
+
+		// JWT провайдера
+		JWTJWKS:          os.Getenv("JWT_JWKS"),
+		JWTJWKSRefresh:   getEnvDurationWithDefault("JWT_JWKS_REFRESH", time.Hour),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+		JWTIdentityClaim: getEnvWithDefault("JWT_IDENTITY_CLAIM", "sub"),
+		JWTRolesClaim:    getEnvWithDefault("JWT_ROLES_CLAIM", "roles"),
+		JWTRoleMap:       os.Getenv("JWT_ROLE_MAP"),
+
+		// Настройки параллельной обработки
+		Concurrency:       getEnvIntWithDefault("MINION_CONCURRENCY", 5),
+		DomainConcurrency: getEnvIntWithDefault("MINION_DOMAIN_CONCURRENCY", 1),
+
+		// Настройки повторных запросов к iiko
+		RetryMaxAttempts: getEnvIntWithDefault("IIKO_RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:   getEnvDurationWithDefault("IIKO_RETRY_BASE_DELAY", 500*time.Millisecond),
+		RetryMaxDelay:    getEnvDurationWithDefault("IIKO_RETRY_MAX_DELAY", 10*time.Second),
+		RetryMutations:   getEnvBoolWithDefault("IIKO_RETRY_MUTATIONS", false),
+
+		// Максимальная длительность одного запуска операции
+		RunTimeout: getEnvDurationWithDefault("MINION_RUN_TIMEOUT", 30*time.Minute),
+
+		// Продление ключей по умолчанию
+		KeyExtensionYears:    getEnvIntWithDefault("KEY_EXTENSION_YEARS", 2),
+		KeyExtensionMonths:   getEnvIntWithDefault("KEY_EXTENSION_MONTHS", 0),
+		KeyExtensionDays:     getEnvIntWithDefault("KEY_EXTENSION_DAYS", 0),
+		KeyMaxExpirationDate: getEnvDateWithDefault("KEY_MAX_EXPIRATION_DATE", time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC)),
+
+		// Политика продления ключей
+		KeyThresholdDays: getEnvIntWithDefault("KEY_EXTENSION_THRESHOLD_DAYS", 30),
+		KeyHorizonDays:   getEnvIntWithDefault("KEY_EXTENSION_HORIZON_DAYS", 0),
+
+		// Встроенный планировщик
+		ScheduleTimezone:     getEnvWithDefault("SCHEDULE_TIMEZONE", "Asia/Almaty"),
+		ScheduleExtendKeys:   os.Getenv("SCHEDULE_EXTEND_KEYS"),
+		ScheduleRefreshMenus: os.Getenv("SCHEDULE_REFRESH_MENUS"),
+
+		// Блокировка операций между репликами
+		LeaseTTL: getEnvDurationWithDefault("MINION_LEASE_TTL", 2*time.Minute),
+
+		// Мониторинг сроков действия ключей
+		MonitorInterval:   getEnvDurationWithDefault("MONITOR_INTERVAL", 6*time.Hour),
+		MonitorThresholds: getEnvWithDefault("MONITOR_THRESHOLDS", "30,7,1"),
+
+		// Оповещения
+		NotifyWebhooks:    loadWebhooks(),
+		NotifyMaxAttempts: getEnvIntWithDefault("NOTIFY_MAX_ATTEMPTS", 3),
+		NotifyTimeout:     getEnvDurationWithDefault("NOTIFY_TIMEOUT", time.Minute),
+
+		// Оповещения ресторанов в WhatsApp
+		WhatsAppGatewayURL:   os.Getenv("WHATSAPP_GATEWAY_URL"),
+		WhatsAppGatewayToken: os.Getenv("WHATSAPP_GATEWAY_TOKEN"),
+		WhatsAppEvents:       getEnvWithDefault("WHATSAPP_EVENTS", "restaurant_failed,key_expiring"),
+		WhatsAppChatLimit:    getEnvIntWithDefault("WHATSAPP_CHAT_LIMIT", 5),
+		WhatsAppChatWindow:   getEnvDurationWithDefault("WHATSAPP_CHAT_WINDOW", time.Hour),
+
+		// Telegram бот операторов
+		TelegramBotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramAPIURL:   getEnvWithDefault("TELEGRAM_API_URL", "https://api.telegram.org"),
+		TelegramChatIDs:  os.Getenv("TELEGRAM_CHAT_IDS"),
+		TelegramEvents:   getEnvWithDefault("TELEGRAM_EVENTS", "run_completed,key_expiring"),
 	}
 }
 
-// LoadRestaurants загружает рестораны из базы данных через AWS Secrets Manager
-func LoadRestaurants(ctx context.Context, envConfig *EnvConfig) ([]*models.Restaurant, error) {
-	// Создаем AWS Secrets Manager клиент
-	awsClient, err := aws.NewSecretsManager(envConfig.AWSRegion)
-	if err != nil {
-		return nil, err
+// loadWebhooks читает webhook-и, перечисленные в NOTIFY_WEBHOOKS (например, ops,crm).
+// Настройки webhook ops берутся из NOTIFY_WEBHOOK_OPS_URL, NOTIFY_WEBHOOK_OPS_SECRET и NOTIFY_WEBHOOK_OPS_EVENTS
+func loadWebhooks() []WebhookConfig {
+	var webhooks []WebhookConfig
+	for _, part := range strings.Split(os.Getenv("NOTIFY_WEBHOOKS"), ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+
+		prefix := "NOTIFY_WEBHOOK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		webhooks = append(webhooks, WebhookConfig{
+			Name:   name,
+			URL:    os.Getenv(prefix + "URL"),
+			Secret: os.Getenv(prefix + "SECRET"),
+			Events: os.Getenv(prefix + "EVENTS"),
+		})
 	}
+	return webhooks
+}
 
-	// Получаем credentials из AWS
-	dbCredentials, err := awsClient.GetDatabaseCredentials(envConfig.AWSSecretName)
+// ConnectDatabase подключается к базе, где хранятся рестораны; в ней же minion хранит
+// блокировки, историю запусков, предупреждения и журнал аудита
+func ConnectDatabase(ctx context.Context, envConfig *EnvConfig) (*mongo.Database, error) {
+	dbCredentials, err := databaseCredentials(ctx, envConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// Создаем сервис для работы с ресторанами
-	restaurantService, err := database.NewRestaurantService(dbCredentials.DbURL, dbCredentials.DbName)
+	return database.Connect(ctx, dbCredentials.DbURL, dbCredentials.DbName)
+}
+
+// SecretAPIKeys получает хеши API ключей из поля minion_api_keys секрета AWS
+func SecretAPIKeys(ctx context.Context, envConfig *EnvConfig) (string, error) {
+	awsClient, err := aws.NewSecretsManager(envConfig.AWSRegion)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer restaurantService.Close()
 
-	// Загружаем рестораны из базы данных
-	mongoRestaurants, err := restaurantService.GetActiveIikoRestaurants()
+	return awsClient.GetAPIKeys(ctx, envConfig.AWSSecretName)
+}
+
+// databaseCredentials получает данные для подключения к MongoDB из AWS Secrets Manager
+func databaseCredentials(ctx context.Context, envConfig *EnvConfig) (*models.DatabaseCredentials, error) {
+	// Создаем AWS Secrets Manager клиент
+	awsClient, err := aws.NewSecretsManager(envConfig.AWSRegion)
 	if err != nil {
 		return nil, err
 	}
 
-	// Конвертируем в формат для minion
-	var restaurants []*models.Restaurant
-	for _, mongoRestaurant := range mongoRestaurants {
-		restaurant := mongoRestaurant.ToMinion()
-		if restaurant != nil {
-			restaurants = append(restaurants, restaurant)
-		}
-	}
-
-	return restaurants, nil
+	// Получаем credentials из AWS
+	return awsClient.GetDatabaseCredentials(ctx, envConfig.AWSSecretName)
 }
 
 // getEnvWithDefault получает значение переменной окружения или возвращает значение по умолчанию
@@ -77,3 +242,80 @@ func getEnvWithDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvIntWithDefault получает целое значение переменной окружения или возвращает значение по умолчанию.
+// Некорректное значение возвращается как 0, чтобы его отловила валидация
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// getEnvDurationWithDefault получает длительность (например, 500ms или 10s) из переменной окружения.
+// Некорректное значение возвращается как 0, чтобы его отловила валидация
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// getEnvBoolWithDefault получает логическое значение переменной окружения или возвращает значение по умолчанию
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvDateWithDefault получает дату (31.12.2099 или 2099-12-31) из переменной окружения.
+// Некорректное значение возвращается как нулевая дата, чтобы его отловила валидация
+func getEnvDateWithDefault(key string, defaultValue time.Time) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := keys.ParseDate(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// DefaultKeyExtension возвращает продление ключей по умолчанию из конфигурации
+func (c *EnvConfig) DefaultKeyExtension() keys.Extension {
+	return keys.Extension{
+		Years:   c.KeyExtensionYears,
+		Months:  c.KeyExtensionMonths,
+		Days:    c.KeyExtensionDays,
+		MaxDate: c.KeyMaxExpirationDate,
+	}
+}
+
+// DefaultKeyPolicy возвращает политику продления ключей из конфигурации
+func (c *EnvConfig) DefaultKeyPolicy() keys.Policy {
+	return keys.Policy{
+		ThresholdDays: c.KeyThresholdDays,
+		HorizonDays:   c.KeyHorizonDays,
+	}
+}
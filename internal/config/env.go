@@ -2,6 +2,17 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"minion/internal/auth"
+	"minion/internal/keys"
+	"minion/internal/monitor"
+	"minion/internal/notify"
+	"minion/internal/schedule"
+	"minion/internal/telegram"
 
 	"github.com/joho/godotenv"
 )
@@ -26,6 +37,140 @@ func ValidateEnvConfig(config *EnvConfig) []string {
 		errors = append(errors, "AWS_SECRET_NAME не может быть пустой")
 	}
 
+	// Аутентификация API
+	if err := auth.NewKeyStore().Add(config.APIKeys); err != nil {
+		errors = append(errors, fmt.Sprintf("API_KEYS: %v", err))
+	}
+
+	if config.JWTJWKS != "" {
+		if config.JWTIssuer == "" {
+			errors = append(errors, "JWT_ISSUER не может быть пустой, если задан JWT_JWKS")
+		}
+		if config.JWTAudience == "" {
+			errors = append(errors, "JWT_AUDIENCE не может быть пустой, если задан JWT_JWKS")
+		}
+		if config.JWTJWKSRefresh < time.Minute {
+			errors = append(errors, "JWT_JWKS_REFRESH должна быть не меньше 1m")
+		}
+		if _, err := auth.ParseRoleMap(config.JWTRoleMap); err != nil {
+			errors = append(errors, fmt.Sprintf("JWT_ROLE_MAP: %v", err))
+		}
+	}
+
+	// Параллельная обработка
+	if config.Concurrency < 1 {
+		errors = append(errors, "MINION_CONCURRENCY должна быть положительным числом")
+	}
+	if config.DomainConcurrency < 1 {
+		errors = append(errors, "MINION_DOMAIN_CONCURRENCY должна быть положительным числом")
+	}
+
+	// Повторные запросы к iiko
+	if config.RetryMaxAttempts < 1 {
+		errors = append(errors, "IIKO_RETRY_MAX_ATTEMPTS должна быть положительным числом")
+	}
+	if config.RetryBaseDelay <= 0 {
+		errors = append(errors, "IIKO_RETRY_BASE_DELAY должна быть положительной длительностью (например, 500ms)")
+	}
+	if config.RetryMaxDelay < config.RetryBaseDelay {
+		errors = append(errors, "IIKO_RETRY_MAX_DELAY не может быть меньше IIKO_RETRY_BASE_DELAY")
+	}
+
+	// Ограничение длительности запуска
+	if config.RunTimeout <= 0 {
+		errors = append(errors, "MINION_RUN_TIMEOUT должна быть положительной длительностью (например, 30m)")
+	}
+
+	// Продление ключей по умолчанию
+	if config.KeyMaxExpirationDate.IsZero() {
+		errors = append(errors, "KEY_MAX_EXPIRATION_DATE должна быть датой в формате ДД.ММ.ГГГГ")
+	}
+	if err := config.DefaultKeyExtension().Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("KEY_EXTENSION_YEARS/MONTHS/DAYS: %v", err))
+	}
+	if err := config.DefaultKeyPolicy().Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("KEY_EXTENSION_THRESHOLD_DAYS/HORIZON_DAYS: %v", err))
+	}
+	if config.ScheduleExtendKeys != "" && config.KeyThresholdDays == 0 && config.KeyHorizonDays == 0 {
+		errors = append(errors, "SCHEDULE_EXTEND_KEYS требует KEY_EXTENSION_THRESHOLD_DAYS или KEY_EXTENSION_HORIZON_DAYS: без них каждый плановый запуск продлевает все ключи")
+	}
+
+	// Блокировка операций между репликами
+	if config.LeaseTTL < 3*time.Second {
+		errors = append(errors, "MINION_LEASE_TTL должна быть не меньше 3s (например, 2m)")
+	}
+
+	// Мониторинг сроков действия ключей
+	if value := os.Getenv("MONITOR_INTERVAL"); value != "" && value != "0" && config.MonitorInterval < time.Minute {
+		errors = append(errors, "MONITOR_INTERVAL должна быть не меньше 1m (например, 6h) или 0, чтобы выключить мониторинг")
+	}
+	if _, err := monitor.ParseThresholds(config.MonitorThresholds); err != nil {
+		errors = append(errors, fmt.Sprintf("MONITOR_THRESHOLDS: %v", err))
+	}
+
+	// Оповещения
+	if config.NotifyMaxAttempts < 1 {
+		errors = append(errors, "NOTIFY_MAX_ATTEMPTS должна быть положительным числом")
+	}
+	if config.NotifyTimeout <= 0 {
+		errors = append(errors, "NOTIFY_TIMEOUT должна быть положительной длительностью (например, 1m)")
+	}
+	for _, webhook := range config.NotifyWebhooks {
+		if !isHTTPURL(webhook.URL) {
+			errors = append(errors, fmt.Sprintf("webhook %s: URL должен быть http(s) адресом", webhook.Name))
+		}
+		if _, err := notify.ParseEvents(webhook.Events); err != nil {
+			errors = append(errors, fmt.Sprintf("webhook %s: %v", webhook.Name, err))
+		}
+	}
+
+	if config.WhatsAppGatewayURL != "" {
+		if !isHTTPURL(config.WhatsAppGatewayURL) {
+			errors = append(errors, "WHATSAPP_GATEWAY_URL должен быть http(s) адресом")
+		}
+		if _, err := notify.ParseEvents(config.WhatsAppEvents); err != nil {
+			errors = append(errors, fmt.Sprintf("WHATSAPP_EVENTS: %v", err))
+		}
+		if config.WhatsAppChatLimit < 1 {
+			errors = append(errors, "WHATSAPP_CHAT_LIMIT должна быть положительным числом")
+		}
+		if config.WhatsAppChatWindow <= 0 {
+			errors = append(errors, "WHATSAPP_CHAT_WINDOW должна быть положительной длительностью (например, 1h)")
+		}
+	}
+
+	// Telegram бот операторов
+	if config.TelegramBotToken != "" {
+		if !isHTTPURL(config.TelegramAPIURL) {
+			errors = append(errors, "TELEGRAM_API_URL должен быть http(s) адресом")
+		}
+		if chats, err := telegram.ParseChatIDs(config.TelegramChatIDs); err != nil {
+			errors = append(errors, fmt.Sprintf("TELEGRAM_CHAT_IDS: %v", err))
+		} else if len(chats) == 0 {
+			errors = append(errors, "TELEGRAM_CHAT_IDS не может быть пустой, если задан TELEGRAM_BOT_TOKEN")
+		}
+		if _, err := notify.ParseEvents(config.TelegramEvents); err != nil {
+			errors = append(errors, fmt.Sprintf("TELEGRAM_EVENTS: %v", err))
+		}
+	}
+
+	// Встроенный планировщик
+	if _, err := time.LoadLocation(config.ScheduleTimezone); err != nil {
+		errors = append(errors, fmt.Sprintf("SCHEDULE_TIMEZONE: неизвестный часовой пояс %s", config.ScheduleTimezone))
+	}
+	schedules := []struct{ name, expression string }{
+		{"SCHEDULE_EXTEND_KEYS", config.ScheduleExtendKeys},
+		{"SCHEDULE_REFRESH_MENUS", config.ScheduleRefreshMenus},
+	}
+	for _, s := range schedules {
+		if s.expression == "" {
+			continue
+		}
+		if _, err := schedule.ParseCron(s.expression); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", s.name, err))
+		}
+	}
+
 	return errors
 }
 
@@ -35,4 +180,40 @@ func PrintEnvConfig(config *EnvConfig) {
 	fmt.Printf("  🚀 HTTP Port: %s\n", config.HTTPPort)
 	fmt.Printf("  🌍 AWS Region: %s\n", config.AWSRegion)
 	fmt.Printf("  🔑 AWS Secret Name: %s\n", config.AWSSecretName)
+	fmt.Printf("  🛡️  Аутентификация: %t, CORS: %s\n", config.AuthEnabled, config.CORSAllowOrigins)
+	if config.JWTJWKS != "" {
+		fmt.Printf("  🪪 JWT: JWKS %s (обновление %s), issuer %s, audience %s, роли из %s\n",
+			config.JWTJWKS, config.JWTJWKSRefresh, config.JWTIssuer, config.JWTAudience, config.JWTRolesClaim)
+	}
+	fmt.Printf("  ⚙️  Concurrency: %d (на домен: %d)\n", config.Concurrency, config.DomainConcurrency)
+	fmt.Printf("  🔁 Retry: %d попыток, задержка %s..%s, изменяющие запросы: %t\n",
+		config.RetryMaxAttempts, config.RetryBaseDelay, config.RetryMaxDelay, config.RetryMutations)
+	fmt.Printf("  ⏱️  Run Timeout: %s\n", config.RunTimeout)
+	fmt.Printf("  📅 Продление ключей: %s, не позже %s\n",
+		config.DefaultKeyExtension(), config.KeyMaxExpirationDate.Format(keys.DateLayout))
+	fmt.Printf("  🗓️  Политика продления: %s\n", config.DefaultKeyPolicy())
+	fmt.Printf("  🔒 Lease TTL: %s\n", config.LeaseTTL)
+	fmt.Printf("  👀 Мониторинг ключей: каждые %s, пороги %s дн.\n", config.MonitorInterval, config.MonitorThresholds)
+	for _, webhook := range config.NotifyWebhooks {
+		events, _ := notify.ParseEvents(webhook.Events)
+		fmt.Printf("  📣 Webhook %s: %s, подпись: %t\n", webhook.Name, strings.Join(events, ","), webhook.Secret != "")
+	}
+	if config.WhatsAppGatewayURL != "" {
+		fmt.Printf("  💬 WhatsApp: %s, не больше %d сообщений в чат за %s\n",
+			config.WhatsAppEvents, config.WhatsAppChatLimit, config.WhatsAppChatWindow)
+	}
+	if config.TelegramBotToken != "" {
+		fmt.Printf("  🤖 Telegram: %s, чаты %s, события %s\n", config.TelegramAPIURL, config.TelegramChatIDs, config.TelegramEvents)
+	}
+	if config.AuditFile != "" {
+		fmt.Printf("  🧾 Журнал аудита: MongoDB и %s\n", config.AuditFile)
+	}
+	fmt.Printf("  ⏰ Расписания (%s): extend-keys %q, refresh-menus %q\n",
+		config.ScheduleTimezone, config.ScheduleExtendKeys, config.ScheduleRefreshMenus)
+}
+
+// isHTTPURL проверяет, что value - абсолютный http(s) адрес
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// alertCollection - коллекция активных предупреждений об истекающих ключах
+const alertCollection = "minion_key_alerts"
+
+// AlertService хранит активные предупреждения об истекающих ключах, общие для всех реплик
+type AlertService struct {
+	collection *mongo.Collection
+}
+
+// NewAlertService создает AlertService поверх общего подключения
+func NewAlertService(db *mongo.Database) *AlertService {
+	return &AlertService{collection: db.Collection(alertCollection)}
+}
+
+// Raise сохраняет предупреждение; true, если его еще не было (нужно оповестить).
+// Уже поднятое предупреждение только обновляет оставшиеся дни и время проверки
+func (as *AlertService) Raise(ctx context.Context, alert models.KeyAlert) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"restaurant_id": alert.RestaurantID,
+			"api_login_id":  alert.ApiLoginID,
+			"threshold":     alert.Threshold,
+			"raised_at":     alert.RaisedAt,
+		},
+		"$set": bson.M{
+			"restaurant":      alert.Restaurant,
+			"api_login":       alert.ApiLogin,
+			"expiration_date": alert.ExpirationDate,
+			"days_remaining":  alert.DaysRemaining,
+			"checked_at":      alert.CheckedAt,
+		},
+	}
+
+	result, err := as.collection.UpdateOne(ctx, bson.M{"_id": alert.ID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения предупреждения %s: %v", alert.ID, err)
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// Clear снимает предупреждения, которых нет среди keep (ключ продлен, отключен или удален), и возвращает их.
+// Предупреждения ресторанов из skipRestaurants не трогаются: их ключи в этот раз не удалось проверить
+func (as *AlertService) Clear(ctx context.Context, keep, skipRestaurants []string) ([]models.KeyAlert, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":           bson.M{"$nin": keep},
+		"restaurant_id": bson.M{"$nin": skipRestaurants},
+	}
+
+	cursor, err := as.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска предупреждений: %v", err)
+	}
+	var cleared []models.KeyAlert
+	if err := cursor.All(ctx, &cleared); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования предупреждений: %v", err)
+	}
+	if len(cleared) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(cleared))
+	for _, alert := range cleared {
+		ids = append(ids, alert.ID)
+	}
+	if _, err := as.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, fmt.Errorf("ошибка снятия предупреждений: %v", err)
+	}
+
+	return cleared, nil
+}
+
+// List возвращает активные предупреждения, начиная с ключей, которые истекают раньше
+func (as *AlertService) List(ctx context.Context) ([]models.KeyAlert, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{
+		{Key: "days_remaining", Value: 1},
+		{Key: "restaurant", Value: 1},
+		{Key: "threshold", Value: 1},
+	})
+
+	cursor, err := as.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска предупреждений: %v", err)
+	}
+
+	alerts := []models.KeyAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования предупреждений: %v", err)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// auditCollection - коллекция журнала аудита
+const auditCollection = "minion_audit"
+
+// AuditService ведет журнал аудита в MongoDB. Записи только добавляются: методов изменения и удаления нет
+type AuditService struct {
+	collection  *mongo.Collection
+	restaurants *mongo.Collection
+}
+
+// NewAuditService создает AuditService поверх общего подключения и индексы журнала
+func NewAuditService(ctx context.Context, db *mongo.Database) (*AuditService, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(auditCollection)
+
+	// Индексы под фильтры GET /api/audit
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "identity", Value: 1}, {Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "selector.ids", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurants.id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания индексов журнала аудита: %v", err)
+	}
+
+	return &AuditService{
+		collection:  collection,
+		restaurants: db.Collection(restaurantCollection),
+	}, nil
+}
+
+// ResolveRestaurants возвращает рестораны, которые выбирает селектор: позже по одному селектору
+// уже нельзя сказать, какие рестораны он выбирал на момент вызова
+func (as *AuditService) ResolveRestaurants(ctx context.Context, selector models.RestaurantSelector) ([]models.AuditRestaurant, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter, err := restaurantFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"name": 1}).
+		SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := as.restaurants.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска ресторанов для аудита: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var found []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ресторанов для аудита: %v", err)
+	}
+
+	restaurants := make([]models.AuditRestaurant, 0, len(found))
+	for _, restaurant := range found {
+		restaurants = append(restaurants, models.AuditRestaurant{ID: restaurant.ID.Hex(), Name: restaurant.Name})
+	}
+	return restaurants, nil
+}
+
+// Insert добавляет запись в журнал
+func (as *AuditService) Insert(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := as.collection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %v", err)
+	}
+	return nil
+}
+
+// Find возвращает записи по фильтру, начиная с самых новых
+func (as *AuditService) Find(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Identity != "" {
+		query["identity"] = filter.Identity
+	}
+	if filter.Endpoint != "" {
+		query["endpoint"] = filter.Endpoint
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if filter.Restaurant != "" {
+		name := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Restaurant) + "$", Options: "i"}
+		query["$or"] = []bson.M{
+			{"selector.ids": filter.Restaurant},
+			{"selector.names": name},
+			{"restaurants.id": filter.Restaurant},
+			{"restaurants.name": name},
+		}
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		at := bson.M{}
+		if !filter.From.IsZero() {
+			at["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			at["$lt"] = filter.To
+		}
+		query["time"] = at
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+
+	cursor, err := as.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска в журнале аудита: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования журнала аудита: %v", err)
+	}
+
+	return entries, nil
+}
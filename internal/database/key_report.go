@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// keyReportCollection - коллекция с последним полным отчетом по ключам
+const keyReportCollection = "minion_key_report"
+
+// keyReportID - _id единственного документа с последним отчетом
+const keyReportID = "latest"
+
+// keyReportDocument - последний полный отчет по ключам
+type keyReportDocument struct {
+	ID     string            `bson:"_id"`
+	Report *models.KeyReport `bson:"report"`
+}
+
+// KeyReportService хранит последний полный отчет по ключам, чтобы его отдавали все реплики,
+// а iiko читала только та, что держит блокировку мониторинга
+type KeyReportService struct {
+	collection *mongo.Collection
+}
+
+// NewKeyReportService создает KeyReportService поверх общего подключения
+func NewKeyReportService(db *mongo.Database) *KeyReportService {
+	return &KeyReportService{collection: db.Collection(keyReportCollection)}
+}
+
+// Save заменяет сохраненный отчет, если он не новее report
+func (ks *KeyReportService) Save(ctx context.Context, report *models.KeyReport) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// Отчет, построенный раньше сохраненного (например, медленной репликой), не перезаписывает новый
+	filter := bson.M{
+		"_id":                 keyReportID,
+		"report.generated_at": bson.M{"$lt": report.GeneratedAt},
+	}
+	_, err := ks.collection.ReplaceOne(ctx, filter, keyReportDocument{ID: keyReportID, Report: report}, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения отчета по ключам: %v", err)
+	}
+	return nil
+}
+
+// Latest возвращает последний сохраненный отчет; nil, если отчета еще нет
+func (ks *KeyReportService) Latest(ctx context.Context) (*models.KeyReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var document keyReportDocument
+	err := ks.collection.FindOne(ctx, bson.M{"_id": keyReportID}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения отчета по ключам: %v", err)
+	}
+	return document.Report, nil
+}
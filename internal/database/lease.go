@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// leaseCollection - коллекция блокировок операций
+const leaseCollection = "minion_leases"
+
+// leaseDocument - документ блокировки; _id - имя блокировки (название операции)
+type leaseDocument struct {
+	Name       string    `bson:"_id"`
+	Token      string    `bson:"token"`
+	Instance   string    `bson:"instance"`
+	JobID      string    `bson:"job_id,omitempty"`
+	AcquiredAt time.Time `bson:"acquired_at"`
+	ExpiresAt  time.Time `bson:"expires_at"`
+}
+
+// LeaseHeldError - блокировку уже держит другой запуск
+type LeaseHeldError struct {
+	Name      string
+	Instance  string
+	JobID     string
+	ExpiresAt time.Time
+}
+
+func (e *LeaseHeldError) Error() string {
+	text := fmt.Sprintf("операция %s уже выполняется на %s", e.Name, e.Instance)
+	if e.JobID != "" {
+		text += fmt.Sprintf(" (задача %s)", e.JobID)
+	}
+	return text
+}
+
+// ErrLeaseLost - блокировка истекла или перехвачена другим запуском
+var ErrLeaseLost = errors.New("блокировка операции потеряна")
+
+// LeaseService выдает блокировки операций, общие для всех реплик minion
+type LeaseService struct {
+	collection *mongo.Collection
+	instance   string
+}
+
+// NewLeaseService создает LeaseService поверх общего подключения и TTL индекс блокировок
+func NewLeaseService(ctx context.Context, db *mongo.Database) (*LeaseService, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(leaseCollection)
+
+	// TTL индекс удаляет блокировки упавших реплик; истекшая блокировка свободна и до удаления
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания TTL индекса блокировок: %v", err)
+	}
+
+	return &LeaseService{
+		collection: collection,
+		instance:   instanceName(),
+	}, nil
+}
+
+// Acquire захватывает блокировку name на ttl; если ее держит другой запуск - LeaseHeldError
+func (ls *LeaseService) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	lease := &Lease{
+		service: ls,
+		name:    name,
+		token:   newToken(),
+		ttl:     ttl,
+	}
+
+	// Захватываем только свободную (истекшую) блокировку; если документ занят, upsert
+	// попытается вставить второй документ с тем же _id и получит ошибку дубликата
+	filter := bson.M{"_id": name, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{
+		"token":       lease.token,
+		"instance":    ls.instance,
+		"job_id":      "",
+		"acquired_at": now,
+		"expires_at":  now.Add(ttl),
+	}}
+
+	_, err := ls.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ls.heldError(ctx, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка захвата блокировки %s: %v", name, err)
+	}
+
+	return lease, nil
+}
+
+// heldError читает текущего держателя блокировки для ответа 409
+func (ls *LeaseService) heldError(ctx context.Context, name string) error {
+	var document leaseDocument
+	if err := ls.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&document); err != nil {
+		return &LeaseHeldError{Name: name, Instance: "неизвестно"}
+	}
+
+	return &LeaseHeldError{
+		Name:      name,
+		Instance:  document.Instance,
+		JobID:     document.JobID,
+		ExpiresAt: document.ExpiresAt,
+	}
+}
+
+// Lease - захваченная блокировка операции
+type Lease struct {
+	service *LeaseService
+	name    string
+	token   string
+	ttl     time.Duration
+}
+
+// SetJobID записывает в блокировку идентификатор задачи, чтобы другие реплики могли его показать
+func (l *Lease) SetJobID(ctx context.Context, jobID string) error {
+	_, err := l.update(ctx, bson.M{"job_id": jobID})
+	return err
+}
+
+// Renew продлевает блокировку еще на ttl; ErrLeaseLost, если блокировка уже не наша
+func (l *Lease) Renew(ctx context.Context) error {
+	matched, err := l.update(ctx, bson.M{"expires_at": time.Now().Add(l.ttl)})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release освобождает блокировку, если она все еще наша
+func (l *Lease) Release(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := l.service.collection.DeleteOne(ctx, bson.M{"_id": l.name, "token": l.token})
+	if err != nil {
+		return fmt.Errorf("ошибка освобождения блокировки %s: %v", l.name, err)
+	}
+	return nil
+}
+
+// Hold продлевает блокировку в фоне, пока не вызвана stop. Возвращенный контекст отменяется,
+// если блокировку продлить не удалось, чтобы две реплики не работали одновременно.
+// stop освобождает блокировку
+func (l *Lease) Hold(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		renewedAt := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := l.Renew(context.Background())
+				if err == nil {
+					renewedAt = time.Now()
+					continue
+				}
+
+				// Временную ошибку базы переживаем, если до истечения блокировки успеем повторить
+				if !errors.Is(err, ErrLeaseLost) && time.Since(renewedAt) < l.ttl*2/3 {
+					log.Printf("⚠️ Не удалось продлить блокировку %s: %v, повторим", l.name, err)
+					continue
+				}
+
+				log.Printf("❌ Блокировка %s потеряна: %v, останавливаем операцию", l.name, err)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	stop := func() {
+		close(done)
+		<-stopped
+		cancel()
+
+		if err := l.Release(context.Background()); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+
+	return ctx, stop
+}
+
+// update меняет поля блокировки, если она все еще наша
+func (l *Lease) update(ctx context.Context, fields bson.M) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := l.service.collection.UpdateOne(ctx, bson.M{"_id": l.name, "token": l.token}, bson.M{"$set": fields})
+	if err != nil {
+		return false, fmt.Errorf("ошибка обновления блокировки %s: %v", l.name, err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// instanceName описывает реплику для сообщений о занятой блокировке
+func instanceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
+
+// newToken генерирует случайный токен владельца блокировки
+func newToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Connect подключается к базе MongoDB. Клиент общий для сервисов minion
+// (блокировки, история, предупреждения, аудит) и закрывается при остановке сервера
+func Connect(ctx context.Context, connectionString, databaseName string) (*mongo.Database, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionString))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к MongoDB: %v", err)
+	}
+
+	// Проверяем соединение сразу, чтобы не сохранить клиент к недоступной базе
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("ошибка проверки подключения к MongoDB: %v", err)
+	}
+
+	return client.Database(databaseName), nil
+}
+
+// Disconnect закрывает общий клиент базы
+func Disconnect(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return db.Client().Disconnect(ctx)
+}
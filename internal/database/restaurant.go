@@ -3,58 +3,64 @@ package database
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"minion/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// restaurantCollection - коллекция ресторанов
+const restaurantCollection = "restaurants"
+
 // RestaurantService предоставляет методы для работы с ресторанами в MongoDB
 type RestaurantService struct {
-	client     *mongo.Client
-	database   *mongo.Database
 	collection *mongo.Collection
 }
 
-// NewRestaurantService создает новый экземпляр RestaurantService
-func NewRestaurantService(connectionString, databaseName string) (*RestaurantService, error) {
-	// Настройки подключения
-	clientOptions := options.Client().ApplyURI(connectionString)
+// NewRestaurantService создает RestaurantService поверх общего подключения
+func NewRestaurantService(db *mongo.Database) *RestaurantService {
+	return &RestaurantService{collection: db.Collection(restaurantCollection)}
+}
 
-	// Создаем контекст с таймаутом для подключения
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// GetActiveIikoRestaurants получает все активные рестораны с типом iiko
+func (rs *RestaurantService) GetActiveIikoRestaurants(ctx context.Context) ([]*models.RestaurantMongo, error) {
+	return rs.FindActiveIikoRestaurants(ctx, models.RestaurantSelector{})
+}
+
+// FindActiveIikoRestaurants получает активные iiko рестораны, подходящие под селектор
+func (rs *RestaurantService) FindActiveIikoRestaurants(ctx context.Context, selector models.RestaurantSelector) ([]*models.RestaurantMongo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Подключаемся к MongoDB
-	client, err := mongo.Connect(ctx, clientOptions)
+	filter, err := restaurantFilter(selector)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка подключения к MongoDB: %v", err)
+		return nil, err
 	}
 
-	// Проверяем соединение
-	if err := client.Ping(ctx, nil); err != nil {
-		return nil, fmt.Errorf("ошибка ping MongoDB: %v", err)
+	// Выполняем поиск
+	cursor, err := rs.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска ресторанов: %v", err)
 	}
+	defer cursor.Close(ctx)
 
-	// Получаем базу данных и коллекцию
-	database := client.Database(databaseName)
-	collection := database.Collection("restaurants")
+	// Декодируем результаты
+	var restaurants []*models.RestaurantMongo
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ресторанов: %v", err)
+	}
 
-	return &RestaurantService{
-		client:     client,
-		database:   database,
-		collection: collection,
-	}, nil
+	return restaurants, nil
 }
 
-// GetActiveIikoRestaurants получает все активные рестораны с типом iiko
-func (rs *RestaurantService) GetActiveIikoRestaurants() ([]*models.RestaurantMongo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
+// restaurantFilter формирует фильтр MongoDB для активных ресторанов с учетом селектора
+func restaurantFilter(selector models.RestaurantSelector) (bson.M, error) {
 	// Фильтр для поиска активных iiko ресторанов
 	filter := bson.M{
 		"pos_type":   "iiko",
@@ -67,26 +73,105 @@ func (rs *RestaurantService) GetActiveIikoRestaurants() ([]*models.RestaurantMon
 		"iiko_cloud.iiko_web_domain": bson.M{"$ne": ""},
 	}
 
-	// Выполняем поиск
-	cursor, err := rs.collection.Find(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка поиска ресторанов: %v", err)
+	if len(selector.IDs) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(selector.IDs))
+		for _, id := range selector.IDs {
+			objectID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return nil, fmt.Errorf("некорректный id ресторана: %s", id)
+			}
+			ids = append(ids, objectID)
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if len(selector.PosTypes) > 0 {
+		filter["pos_type"] = bson.M{"$in": selector.PosTypes}
 	}
-	defer cursor.Close(ctx)
 
-	// Декодируем результаты
-	var restaurants []*models.RestaurantMongo
-	if err := cursor.All(ctx, &restaurants); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ресторанов: %v", err)
+	// Остальные условия складываем через $and, чтобы не перезаписать условия выше
+	var conditions []bson.M
+	if len(selector.Names) > 0 {
+		conditions = append(conditions, bson.M{"name": bson.M{"$in": exactMatches(selector.Names)}})
+	}
+	if len(selector.Cities) > 0 {
+		conditions = append(conditions, bson.M{"city": bson.M{"$in": exactMatches(selector.Cities)}})
+	}
+	if len(selector.Domains) > 0 {
+		domains := make([]string, 0, len(selector.Domains))
+		for _, domain := range selector.Domains {
+			domains = append(domains, models.NormalizeDomain(domain))
+		}
+		conditions = append(conditions, bson.M{"iiko_cloud.iiko_web_domain": bson.M{"$in": exactMatches(domains)}})
+	}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
 	}
 
-	return restaurants, nil
+	return filter, nil
 }
 
-// Close закрывает соединение с базой данных
-func (rs *RestaurantService) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// exactMatches превращает значения в регулярные выражения для точного совпадения без учета регистра
+func exactMatches(values []string) []primitive.Regex {
+	regexes := make([]primitive.Regex, 0, len(values))
+	for _, value := range values {
+		regexes = append(regexes, primitive.Regex{
+			Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(value)) + "$",
+			Options: "i",
+		})
+	}
+	return regexes
+}
+
+// UpdateMinionStatuses записывает итоги запуска в поля minion.* документов ресторанов
+func (rs *RestaurantService) UpdateMinionStatuses(ctx context.Context, updates []models.MinionStatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	return rs.client.Disconnect(ctx)
+	writes := make([]mongo.WriteModel, 0, len(updates))
+	for _, update := range updates {
+		id, err := primitive.ObjectIDFromHex(update.RestaurantID)
+		if err != nil {
+			return fmt.Errorf("некорректный id ресторана: %s", update.RestaurantID)
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(minionStatusUpdate(update)))
+	}
+
+	// Порядок не важен: каждый документ обновляется независимо
+	_, err := rs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("ошибка записи статуса minion в рестораны: %v", err)
+	}
+
+	return nil
+}
+
+// minionStatusUpdate формирует $set/$unset только по полям minion.*, не затрагивая настройки ресторана
+func minionStatusUpdate(update models.MinionStatusUpdate) bson.M {
+	set := bson.M{"minion.last_run_id": update.RunID}
+	if update.LastKeyExtensionAt != nil {
+		set["minion.last_key_extension_at"] = *update.LastKeyExtensionAt
+	}
+	if update.KeyExpiresAt != nil {
+		set["minion.key_expires_at"] = *update.KeyExpiresAt
+	}
+	if update.LastMenuRefreshAt != nil {
+		set["minion.last_menu_refresh_at"] = *update.LastMenuRefreshAt
+	}
+
+	if update.LastError == "" {
+		return bson.M{
+			"$set":   set,
+			"$unset": bson.M{"minion.last_error": "", "minion.last_error_at": ""},
+		}
+	}
+
+	set["minion.last_error"] = update.LastError
+	set["minion.last_error_at"] = update.At
+	return bson.M{"$set": set}
 }
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestaurantFilter(t *testing.T) {
+	const hexID = "64b7f0c2a1b2c3d4e5f60718"
+	objectID, _ := primitive.ObjectIDFromHex(hexID)
+
+	base := func() bson.M {
+		return bson.M{
+			"pos_type":   "iiko",
+			"is_deleted": bson.M{"$ne": true},
+			"$or": []bson.M{
+				{"settings.is_deleted": bson.M{"$ne": true}},
+				{"settings.is_deleted": bson.M{"$exists": false}},
+			},
+			"iiko_cloud.iiko_web_domain": bson.M{"$ne": ""},
+		}
+	}
+	with := func(fields bson.M) bson.M {
+		filter := base()
+		for key, value := range fields {
+			filter[key] = value
+		}
+		return filter
+	}
+	exact := func(pattern string) primitive.Regex {
+		return primitive.Regex{Pattern: pattern, Options: "i"}
+	}
+
+	tests := []struct {
+		name     string
+		selector models.RestaurantSelector
+		want     bson.M
+	}{
+		{
+			name: "пустой селектор - все iiko рестораны",
+			want: base(),
+		},
+		{
+			name:     "по id",
+			selector: models.RestaurantSelector{IDs: []string{hexID}},
+			want:     with(bson.M{"_id": bson.M{"$in": []primitive.ObjectID{objectID}}}),
+		},
+		{
+			name:     "pos_types заменяют условие iiko",
+			selector: models.RestaurantSelector{PosTypes: []string{"iiko", "rkeeper"}},
+			want:     with(bson.M{"pos_type": bson.M{"$in": []string{"iiko", "rkeeper"}}}),
+		},
+		{
+			name:     "названия без учета регистра и спецсимволов",
+			selector: models.RestaurantSelector{Names: []string{" Кафе (центр) "}},
+			want: with(bson.M{"$and": []bson.M{
+				{"name": bson.M{"$in": []primitive.Regex{exact(`^Кафе \(центр\)$`)}}},
+			}}),
+		},
+		{
+			name:     "домены нормализуются",
+			selector: models.RestaurantSelector{Domains: []string{"https://Cafe.iikoweb.ru/"}},
+			want: with(bson.M{"$and": []bson.M{
+				{"iiko_cloud.iiko_web_domain": bson.M{"$in": []primitive.Regex{exact(`^cafe\.iikoweb\.ru$`)}}},
+			}}),
+		},
+		{
+			name: "разные поля объединяются через И",
+			selector: models.RestaurantSelector{
+				Names:   []string{"Кафе"},
+				Cities:  []string{"Москва", "Казань"},
+				Domains: []string{"cafe.iikoweb.ru"},
+			},
+			want: with(bson.M{"$and": []bson.M{
+				{"name": bson.M{"$in": []primitive.Regex{exact("^Кафе$")}}},
+				{"city": bson.M{"$in": []primitive.Regex{exact("^Москва$"), exact("^Казань$")}}},
+				{"iiko_cloud.iiko_web_domain": bson.M{"$in": []primitive.Regex{exact(`^cafe\.iikoweb\.ru$`)}}},
+			}}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := restaurantFilter(tt.selector)
+			if err != nil {
+				t.Fatalf("restaurantFilter() вернул ошибку: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restaurantFilter() = %v\nожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestaurantFilterInvalidID(t *testing.T) {
+	_, err := restaurantFilter(models.RestaurantSelector{IDs: []string{"not-an-id"}})
+	if err == nil {
+		t.Error("ожидалась ошибка для некорректного id")
+	}
+}
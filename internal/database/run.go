@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"minion/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// runCollection - коллекция истории запусков
+const runCollection = "minion_runs"
+
+// ErrRunNotFound - запуск с таким идентификатором не найден
+var ErrRunNotFound = errors.New("запуск не найден")
+
+// RunService хранит историю запусков операций в MongoDB
+type RunService struct {
+	collection *mongo.Collection
+}
+
+// NewRunService создает RunService поверх общего подключения и индексы истории
+func NewRunService(ctx context.Context, db *mongo.Database) (*RunService, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(runCollection)
+
+	// Индексы под фильтры GET /api/runs
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+		{Keys: bson.D{{Key: "operation", Value: 1}, {Key: "started_at", Value: -1}}},
+		{Keys: bson.D{{Key: "result.details.id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания индексов истории запусков: %v", err)
+	}
+
+	return &RunService{collection: collection}, nil
+}
+
+// Save создает или обновляет запись о запуске
+func (rs *RunService) Save(ctx context.Context, run *models.Run) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := rs.collection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения запуска %s: %v", run.ID, err)
+	}
+	return nil
+}
+
+// Get возвращает запуск с результатами по всем ресторанам
+func (rs *RunService) Get(ctx context.Context, id string) (*models.Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var run models.Run
+	err := rs.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения запуска %s: %v", id, err)
+	}
+	return &run, nil
+}
+
+// Find возвращает запуски по фильтру, начиная с самых новых, без результатов по ресторанам
+func (rs *RunService) Find(ctx context.Context, filter models.RunFilter) ([]models.Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Operation != "" {
+		query["operation"] = filter.Operation
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Restaurant != "" {
+		query["$or"] = []bson.M{
+			{"result.details.id": filter.Restaurant},
+			{"result.details.name": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Restaurant) + "$", Options: "i"}},
+		}
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		startedAt := bson.M{}
+		if !filter.From.IsZero() {
+			startedAt["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			startedAt["$lt"] = filter.To
+		}
+		query["started_at"] = startedAt
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetProjection(bson.M{"result.details": 0})
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+
+	cursor, err := rs.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска запусков: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.Run{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования запусков: %v", err)
+	}
+
+	return runs, nil
+}
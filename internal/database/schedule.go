@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scheduleCollection - коллекция состояния расписаний, общего для всех реплик
+const scheduleCollection = "minion_schedules"
+
+// scheduleDocument - состояние расписания; _id - имя расписания (название операции)
+type scheduleDocument struct {
+	Name      string    `bson:"_id"`
+	Paused    bool      `bson:"paused"`
+	UpdatedBy string    `bson:"updated_by"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// ScheduleService хранит паузы расписаний, чтобы пауза через любую реплику действовала на все
+type ScheduleService struct {
+	collection *mongo.Collection
+}
+
+// NewScheduleService создает ScheduleService поверх общего подключения
+func NewScheduleService(db *mongo.Database) *ScheduleService {
+	return &ScheduleService{collection: db.Collection(scheduleCollection)}
+}
+
+// SetPaused ставит расписание на паузу или снимает с нее; by - кто изменил состояние
+func (ss *ScheduleService) SetPaused(ctx context.Context, name string, paused bool, by string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"paused":     paused,
+		"updated_by": by,
+		"updated_at": time.Now(),
+	}}
+	_, err := ss.collection.UpdateOne(ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния расписания %s: %v", name, err)
+	}
+	return nil
+}
+
+// Paused возвращает, стоит ли расписание на паузе; расписание без документа не приостановлено
+func (ss *ScheduleService) Paused(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var document scheduleDocument
+	err := ss.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения состояния расписания %s: %v", name, err)
+	}
+	return document.Paused, nil
+}
@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
-	"minion/internal/client"
 	"minion/internal/config"
+	"minion/internal/jobs"
+	"minion/internal/keys"
 	"minion/internal/models"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,24 +22,6 @@ type APIResponse struct {
 	Error   string      `json:"error,omitempty"`
 }
 
-// OperationResult содержит результаты выполнения операции
-type OperationResult struct {
-	ProcessedRestaurants int                `json:"processed_restaurants"`
-	Successful           int                `json:"successful"`
-	Failed               int                `json:"failed"`
-	Duration             string             `json:"duration"`
-	Details              []RestaurantResult `json:"details"`
-}
-
-// RestaurantResult содержит результат обработки одного ресторана
-type RestaurantResult struct {
-	Name    string `json:"name"`
-	Success bool   `json:"success"`
-	Updated int    `json:"updated"`
-	Message string `json:"message,omitempty"`
-	Error   string `json:"error,omitempty"`
-}
-
 // HealthCheck проверка состояния сервиса
 func HealthCheck(c *fiber.Ctx) error {
 	return c.JSON(APIResponse{
@@ -61,252 +43,109 @@ func GetConfig(c *fiber.Ctx) error {
 		Success: true,
 		Message: "🔧 Текущая конфигурация",
 		Data: fiber.Map{
-			"aws_region":      envConfig.AWSRegion,
-			"aws_secret_name": envConfig.AWSSecretName,
+			"aws_region":         envConfig.AWSRegion,
+			"aws_secret_name":    envConfig.AWSSecretName,
+			"concurrency":        envConfig.Concurrency,
+			"domain_concurrency": envConfig.DomainConcurrency,
+			"key_extension":      envConfig.DefaultKeyExtension().String(),
+			"key_max_expiration": envConfig.KeyMaxExpirationDate.Format(keys.DateLayout),
+			"key_policy":         envConfig.DefaultKeyPolicy().String(),
 		},
 	})
 }
 
 // ExtendKeys обработчик продления API ключей
 func ExtendKeys(c *fiber.Ctx) error {
-	log.Printf("🔑 API запрос: продление ключей от %s", c.IP())
-
-	startTime := time.Now()
-
-	// Загружаем рестораны
-	restaurants, extensionYears, err := loadRestaurants()
+	request, err := parseOperationRequest(c)
 	if err != nil {
-		log.Printf("❌ Ошибка загрузки ресторанов: %v", err)
-		return c.Status(500).JSON(APIResponse{
-			Success: false,
-			Message: "Ошибка загрузки ресторанов",
-			Error:   err.Error(),
-		})
+		return badRequest(c, err)
 	}
+	setAuditTarget(c, request.Restaurants, "")
 
-	result := OperationResult{
-		ProcessedRestaurants: len(restaurants),
-		Details:              make([]RestaurantResult, 0),
-	}
-
-	// Обрабатываем каждый ресторан
-	for _, restaurant := range restaurants {
-		if !restaurant.Enabled {
-			log.Printf("⏭️  Ресторан %s отключен, пропускаем", restaurant.Name)
-			continue
-		}
-
-		restaurantResult := RestaurantResult{
-			Name: restaurant.Name,
-		}
-
-		updated, err := processExtendKeys(*restaurant, extensionYears)
-		if err != nil {
-			log.Printf("❌ Ошибка обработки ресторана %s: %v", restaurant.Name, err)
-			restaurantResult.Success = false
-			restaurantResult.Error = err.Error()
-			result.Failed++
-		} else {
-			log.Printf("✅ Ресторан %s: обновлено %d ключей", restaurant.Name, updated)
-			restaurantResult.Success = true
-			restaurantResult.Updated = updated
-			restaurantResult.Message = fmt.Sprintf("Обновлено %d ключей", updated)
-			result.Successful++
-		}
-
-		result.Details = append(result.Details, restaurantResult)
+	job, extension, err := submitExtendKeys(request, manualTrigger(c))
+	if err != nil {
+		return submitError(c, err)
 	}
+	setAuditTarget(c, request.Restaurants, job.ID())
 
-	result.Duration = time.Since(startTime).String()
+	data := jobAccepted(job)
+	data["extension"] = extension.String()
+	data["selector"] = request.Restaurants.String()
 
-	log.Printf("🎉 GELATO! Продление ключей завершено: %d успешно, %d ошибок",
-		result.Successful, result.Failed)
-
-	return c.JSON(APIResponse{
+	return c.Status(fiber.StatusAccepted).JSON(APIResponse{
 		Success: true,
-		Message: fmt.Sprintf("🎉 GELATO! Обработано %d ресторанов", result.ProcessedRestaurants),
-		Data:    result,
+		Message: "🚀 Продление ключей запущено",
+		Data:    data,
 	})
 }
 
 // RefreshMenus обработчик обновления меню
 func RefreshMenus(c *fiber.Ctx) error {
-	log.Printf("🍽️ API запрос: обновление меню от %s", c.IP())
-
-	startTime := time.Now()
-
-	// Загружаем рестораны
-	restaurants, _, err := loadRestaurants()
+	request, err := parseOperationRequest(c)
 	if err != nil {
-		log.Printf("❌ Ошибка загрузки ресторанов: %v", err)
-		return c.Status(500).JSON(APIResponse{
-			Success: false,
-			Message: "Ошибка загрузки ресторанов",
-			Error:   err.Error(),
-		})
-	}
-
-	result := OperationResult{
-		ProcessedRestaurants: len(restaurants),
-		Details:              make([]RestaurantResult, 0),
+		return badRequest(c, err)
 	}
+	setAuditTarget(c, request.Restaurants, "")
 
-	// Обрабатываем каждый ресторан
-	for _, restaurant := range restaurants {
-		if !restaurant.Enabled {
-			log.Printf("⏭️  Ресторан %s отключен, пропускаем", restaurant.Name)
-			continue
-		}
-
-		restaurantResult := RestaurantResult{
-			Name: restaurant.Name,
-		}
-
-		updated, err := processRefreshMenus(*restaurant)
-		if err != nil {
-			log.Printf("❌ Ошибка обработки ресторана %s: %v", restaurant.Name, err)
-			restaurantResult.Success = false
-			restaurantResult.Error = err.Error()
-			result.Failed++
-		} else {
-			log.Printf("✅ Ресторан %s: обновлено %d меню", restaurant.Name, updated)
-			restaurantResult.Success = true
-			restaurantResult.Updated = updated
-			restaurantResult.Message = fmt.Sprintf("Обновлено %d меню", updated)
-			result.Successful++
-		}
-
-		result.Details = append(result.Details, restaurantResult)
+	job, err := submitRefreshMenus(request, manualTrigger(c))
+	if err != nil {
+		return submitError(c, err)
 	}
+	setAuditTarget(c, request.Restaurants, job.ID())
 
-	result.Duration = time.Since(startTime).String()
-
-	log.Printf("🎉 GELATO! Обновление меню завершено: %d успешно, %d ошибок",
-		result.Successful, result.Failed)
+	data := jobAccepted(job)
+	data["selector"] = request.Restaurants.String()
 
-	return c.JSON(APIResponse{
+	return c.Status(fiber.StatusAccepted).JSON(APIResponse{
 		Success: true,
-		Message: fmt.Sprintf("🎉 GELATO! Обработано %d ресторанов", result.ProcessedRestaurants),
-		Data:    result,
+		Message: "🚀 Обновление меню запущено",
+		Data:    data,
 	})
 }
 
-// loadRestaurants загружает рестораны из базы данных
-func loadRestaurants() ([]*models.Restaurant, int, error) {
-	envConfig := config.LoadEnvConfig()
-	restaurants, err := config.LoadRestaurants(context.Background(), envConfig)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Возвращаем дефолтное значение для extension_years
-	return restaurants, 2, nil
-}
-
-// processExtendKeys обрабатывает продление ключей для одного ресторана
-func processExtendKeys(restaurant models.Restaurant, extensionYears int) (int, error) {
-	apiClient := client.NewIikoClient(restaurant.BaseURL)
-
-	// Авторизация
-	sessionID, err := apiClient.Login(restaurant.Login, restaurant.Password)
+// submitExtendKeys проверяет параметры, захватывает блокировку и ставит продление ключей в очередь задач.
+// Используется и API, и планировщиком; trigger - кто запустил операцию, для логов и истории
+func submitExtendKeys(request OperationRequest, trigger models.Trigger) (*jobs.Job, keys.Extension, error) {
+	extension, err := request.keyExtension(config.LoadEnvConfig().DefaultKeyExtension())
 	if err != nil {
-		return 0, fmt.Errorf("ошибка авторизации: %v", err)
+		return nil, extension, &invalidRequestError{err: err}
 	}
 
-	// Получение API логинов
-	response, err := apiClient.GetApiLogins(sessionID)
+	lease, err := acquireOperationLease(models.OperationExtendKeys, request.DryRun)
 	if err != nil {
-		return 0, fmt.Errorf("ошибка получения API логинов: %v", err)
+		return nil, extension, err
 	}
 
-	updatedCount := 0
-	for _, apiLogin := range response.ApiLogins {
-		for _, externalMenu := range apiLogin.ExternalMenus {
-			if externalMenu.ID == restaurant.IikoExternalMenuId {
-				if !apiLogin.IsActive {
-					continue
-				}
-
-				// Получаем детальную информацию
-				detailResponse, err := apiClient.GetApiLoginDetail(sessionID, apiLogin.ID)
-				if err != nil {
-					continue
-				}
-
-				if detailResponse.ApiLoginInfo.ExpirationDate == nil {
-					continue
-				}
-
-				newExpirationDate, err := extendExpirationDate(*detailResponse.ApiLoginInfo.ExpirationDate, extensionYears)
-				if err != nil {
-					continue
-				}
-
-				if newExpirationDate == *detailResponse.ApiLoginInfo.ExpirationDate {
-					continue
-				}
+	log.Printf("🔑 Продление ключей %s для %s, источник: %s (dry run: %t)", extension, request.Restaurants, trigger, request.DryRun)
 
-				// Обновляем дату
-				detailResponse.ApiLoginInfo.ExpirationDate = &newExpirationDate
-				err = apiClient.SaveApiLoginDetail(sessionID, detailResponse.ApiLoginInfo)
-				if err != nil {
-					continue
-				}
+	params := runParams(request, &extension)
+	run := withMetrics(models.OperationExtendKeys, params, withHistory(models.OperationExtendKeys, trigger, params, func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		return runExtendKeys(ctx, job, request, extension)
+	}))
+	job := jobManager.Submit(models.OperationExtendKeys, withLease(lease, withNotifications(models.OperationExtendKeys, trigger, params, run)))
 
-				updatedCount++
-				break
-			}
-		}
-	}
-
-	return updatedCount, nil
+	return job, extension, nil
 }
 
-// processRefreshMenus обрабатывает обновление меню для одного ресторана
-func processRefreshMenus(restaurant models.Restaurant) (int, error) {
-	apiClient := client.NewIikoClient(restaurant.BaseURL)
-
-	// Авторизация
-	sessionID, err := apiClient.Login(restaurant.Login, restaurant.Password)
-	if err != nil {
-		return 0, fmt.Errorf("ошибка авторизации: %v", err)
+// submitRefreshMenus проверяет параметры, захватывает блокировку и ставит обновление меню в очередь задач.
+// Используется и API, и планировщиком; trigger - кто запустил операцию, для логов и истории
+func submitRefreshMenus(request OperationRequest, trigger models.Trigger) (*jobs.Job, error) {
+	if request.hasKeyExtension() {
+		return nil, &invalidRequestError{err: fmt.Errorf("параметры продления ключей не применимы к обновлению меню")}
 	}
 
-	// Получение списка внешних меню
-	menus, err := apiClient.GetExternalMenus(sessionID)
+	lease, err := acquireOperationLease(models.OperationRefreshMenus, request.DryRun)
 	if err != nil {
-		return 0, fmt.Errorf("ошибка получения меню: %v", err)
+		return nil, err
 	}
 
-	updatedCount := 0
-	for _, menu := range menus.Data {
-		if strconv.Itoa(menu.ID) == restaurant.IikoExternalMenuId {
-			err := apiClient.RefreshExternalMenu(sessionID, menu.ID)
-			if err != nil {
-				continue
-			}
-			updatedCount++
-		}
-	}
-	return updatedCount, nil
-}
+	log.Printf("🍽️ Обновление меню для %s, источник: %s (dry run: %t)", request.Restaurants, trigger, request.DryRun)
 
-// extendExpirationDate продлевает дату истечения на указанное количество лет
-func extendExpirationDate(currentDate string, years int) (string, error) {
-	// Парсим текущую дату
-	parsedTime, err := time.Parse("02.01.2006", currentDate)
-	if err != nil {
-		return "", fmt.Errorf("ошибка парсинга даты %s: %v", currentDate, err)
-	}
-
-	// Добавляем годы
-	newTime := parsedTime.AddDate(years, 0, 0)
-
-	// Максимальная дата - 31.12.2099
-	maxDate := time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC)
-	if newTime.After(maxDate) {
-		newTime = maxDate
-	}
+	params := runParams(request, nil)
+	run := withMetrics(models.OperationRefreshMenus, params, withHistory(models.OperationRefreshMenus, trigger, params, func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		return runRefreshMenus(ctx, job, request)
+	}))
+	job := jobManager.Submit(models.OperationRefreshMenus, withLease(lease, withNotifications(models.OperationRefreshMenus, trigger, params, run)))
 
-	return newTime.Format("02.01.2006"), nil
+	return job, nil
 }
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"minion/internal/auth"
+	"minion/internal/config"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// auditLocal - ключ Locals, в который обработчик кладет рестораны и задачу вызова для аудита
+const auditLocal = "audit"
+
+// auditQueueSize - сколько записей аудита может ждать записи; при переполнении запись остается только в логе
+const auditQueueSize = 1000
+
+// auditBodyLimit - наибольший размер тела запроса в байтах, которое сохраняется в журнале аудита
+const auditBodyLimit = 4 << 10
+
+// auditWriter пишет журнал аудита в фоне, чтобы запись в MongoDB и файл не задерживала ответ.
+// queue - nil, пока запись не запущена и после остановки
+var auditWriter struct {
+	sync.Mutex
+	queue chan *models.AuditEntry
+	done  chan struct{}
+}
+
+// auditTarget - то, что обработчик знает о цели вызова
+type auditTarget struct {
+	selector *models.RestaurantSelector
+	jobID    string
+}
+
+// Audit записывает изменяющий вызов API в журнал аудита после его обработки; GET запросы не пишутся.
+// Ставится перед аутентификацией, чтобы в журнал попадали и отклоненные попытки
+func Audit(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return c.Next()
+	}
+
+	// Пока выполняется Audit, текущий маршрут - его middleware
+	middleware := c.Route()
+	err := c.Next()
+
+	// Строки Fiber действительны только до конца обработчика, а запись пишется в фоне
+	entry := &models.AuditEntry{
+		Time:     time.Now(),
+		Source:   models.AuditSourceAPI,
+		Identity: RequestIdentity(c),
+		IP:       utils.CopyString(c.IP()),
+		Method:   utils.CopyString(c.Method()),
+		Path:     utils.CopyString(c.Path()),
+		Status:   responseStatus(c, err),
+	}
+	entry.Outcome = auditOutcome(entry.Status)
+
+	// Если текущий маршрут остался маршрутом middleware, запрос не дошел до обработчика:
+	// отклонен аутентификацией или маршрута нет. От анонимных запросов пишем только
+	// метод, путь, статус и адрес, чтобы журнал нельзя было заполнить произвольными данными
+	route := c.Route()
+	if route == middleware || entry.Identity == "-" {
+		entry.Endpoint = entry.Path
+		recordAudit(entry)
+		return err
+	}
+
+	entry.Endpoint = route.Path
+	entry.Params = auditParams(c)
+	if identity, ok := c.Locals(identityLocal).(auth.Identity); ok {
+		entry.Role = identity.Role
+	}
+	if target, ok := c.Locals(auditLocal).(auditTarget); ok {
+		entry.Selector = target.selector
+		entry.JobID = target.jobID
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		var response APIResponse
+		if json.Unmarshal(c.Response().Body(), &response) == nil {
+			entry.Error = response.Error
+		}
+	}
+
+	recordAudit(entry)
+	return err
+}
+
+// setAuditTarget сообщает аудиту, к каким ресторанам относится вызов и какую задачу он создал
+func setAuditTarget(c *fiber.Ctx, selector models.RestaurantSelector, jobID string) {
+	c.Locals(auditLocal, auditTarget{selector: &selector, jobID: jobID})
+}
+
+// auditParams собирает тело, query и параметры пути запроса; тело больше auditBodyLimit не сохраняется
+func auditParams(c *fiber.Ctx) models.AuditParams {
+	var params models.AuditParams
+
+	if body := c.Body(); len(body) > auditBodyLimit {
+		params.Body = fmt.Sprintf("тело запроса %d байт не сохранено", len(body))
+	} else if len(body) > 0 {
+		var decoded interface{}
+		if json.Unmarshal(body, &decoded) == nil {
+			params.Body = decoded
+		} else {
+			params.Body = string(body)
+		}
+	}
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if params.Query == nil {
+			params.Query = map[string]string{}
+		}
+		params.Query[string(key)] = string(value)
+	})
+
+	for name, value := range c.AllParams() {
+		if params.Path == nil {
+			params.Path = map[string]string{}
+		}
+		params.Path[name] = utils.CopyString(value)
+	}
+
+	return params
+}
+
+// auditOutcome переводит HTTP статус в итог вызова
+func auditOutcome(status int) string {
+	switch {
+	case status >= 500:
+		return models.AuditOutcomeError
+	case status >= 400:
+		return models.AuditOutcomeRejected
+	}
+	return models.AuditOutcomeSuccess
+}
+
+// StartAudit запускает фоновую запись журнала аудита
+func StartAudit() {
+	auditWriter.Lock()
+	defer auditWriter.Unlock()
+
+	auditWriter.queue = make(chan *models.AuditEntry, auditQueueSize)
+	auditWriter.done = make(chan struct{})
+	go writeAudit(auditWriter.queue, auditWriter.done)
+}
+
+// stopAudit дописывает записи из очереди и останавливает фоновую запись, ожидая не дольше timeout
+func stopAudit(timeout time.Duration) {
+	auditWriter.Lock()
+	queue, done := auditWriter.queue, auditWriter.done
+	auditWriter.queue = nil
+	auditWriter.Unlock()
+
+	if queue == nil {
+		return
+	}
+	close(queue)
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("⚠️ Журнал аудита не дописан за %s, оставшиеся записи есть только в логе", timeout)
+	}
+}
+
+// recordAudit ставит вызов в очередь записи журнала аудита и сразу возвращается
+func recordAudit(entry *models.AuditEntry) {
+	// Один идентификатор у записи в логе, файле и MongoDB
+	entry.ID = primitive.NewObjectID()
+
+	log.Printf("🧾 Аудит %s: %s %s %s от %s (%s): %d %s", entry.ID.Hex(), entry.Source, entry.Method, entry.Path, entry.Identity, entry.IP, entry.Status, entry.Outcome)
+
+	auditWriter.Lock()
+	defer auditWriter.Unlock()
+
+	if auditWriter.queue == nil {
+		log.Printf("⚠️ Журнал аудита остановлен, запись %s не сохранена", entry.ID.Hex())
+		return
+	}
+	select {
+	case auditWriter.queue <- entry:
+	default:
+		log.Printf("⚠️ Очередь журнала аудита переполнена, запись %s не сохранена", entry.ID.Hex())
+	}
+}
+
+// writeAudit пишет записи из очереди, пока очередь не закрыта
+func writeAudit(queue <-chan *models.AuditEntry, done chan<- struct{}) {
+	defer close(done)
+
+	auditFile := config.LoadEnvConfig().AuditFile
+	for entry := range queue {
+		saveAudit(auditFile, entry)
+	}
+}
+
+// saveAudit дополняет запись выбранными ресторанами и пишет ее в AUDIT_FILE и minion_audit.
+// Ошибка записи только логируется: вызов к этому моменту уже выполнен
+func saveAudit(auditFile string, entry *models.AuditEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	auditService, err := auditStore(ctx)
+	if err != nil {
+		log.Printf("⚠️ Не удалось записать аудит %s в MongoDB: %v", entry.ID.Hex(), err)
+	} else if entry.Selector != nil {
+		if entry.Restaurants, err = auditService.ResolveRestaurants(ctx, *entry.Selector); err != nil {
+			log.Printf("⚠️ Аудит %s: %v", entry.ID.Hex(), err)
+		}
+	}
+
+	if auditFile != "" {
+		if err := appendAuditFile(auditFile, entry); err != nil {
+			log.Printf("⚠️ Не удалось записать аудит в %s: %v", auditFile, err)
+		}
+	}
+
+	if auditService != nil {
+		if err := auditService.Insert(ctx, entry); err != nil {
+			log.Printf("⚠️ Аудит %s: %v", entry.ID.Hex(), err)
+		}
+	}
+}
+
+// appendAuditFile дописывает запись строкой JSON в конец файла
+func appendAuditFile(path string, entry *models.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// GetAudit возвращает записи журнала аудита с фильтрами
+func GetAudit(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	auditService, err := auditStore(c.UserContext())
+	if err != nil {
+		return auditUnavailable(c, err)
+	}
+
+	entries, err := auditService.Find(c.UserContext(), filter)
+	if err != nil {
+		return auditUnavailable(c, err)
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "🧾 Журнал аудита",
+		Data:    entries,
+	})
+}
+
+// parseAuditFilter разбирает query параметры GET /api/audit
+func parseAuditFilter(c *fiber.Ctx) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Identity:   strings.TrimSpace(c.Query("identity")),
+		Endpoint:   strings.TrimSpace(c.Query("endpoint")),
+		Outcome:    c.Query("outcome"),
+		Restaurant: strings.TrimSpace(c.Query("restaurant")),
+		Limit:      defaultRunsLimit,
+	}
+
+	switch filter.Outcome {
+	case "", models.AuditOutcomeSuccess, models.AuditOutcomeRejected, models.AuditOutcomeError:
+	default:
+		return filter, fmt.Errorf("неизвестный итог: %s", filter.Outcome)
+	}
+
+	var err error
+	if filter.From, err = parseRunTime(c.Query("from"), false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseRunTime(c.Query("to"), true); err != nil {
+		return filter, err
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 || limit > maxRunsLimit {
+			return filter, fmt.Errorf("limit должен быть числом от 1 до %d", maxRunsLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// auditUnavailable возвращает ответ 503, если журнал аудита недоступен
+func auditUnavailable(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+		Success: false,
+		Message: "Журнал аудита недоступен",
+		Error:   err.Error(),
+	})
+}
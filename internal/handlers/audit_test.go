@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"minion/internal/auth"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// useAPIKeys включает аутентификацию с API ключами "имя:роль:ключ" на время теста
+func useAPIKeys(t *testing.T, apiKeys ...string) {
+	t.Helper()
+
+	store := auth.NewKeyStore()
+	for _, apiKey := range apiKeys {
+		name, rest, _ := strings.Cut(apiKey, ":")
+		role, key, _ := strings.Cut(rest, ":")
+		sum := sha256.Sum256([]byte(key))
+		if err := store.Add(name + ":" + role + ":" + hex.EncodeToString(sum[:])); err != nil {
+			t.Fatalf("KeyStore.Add: %v", err)
+		}
+	}
+
+	previousStore, previousEnabled := keyStore, authEnabled
+	keyStore, authEnabled = store, true
+	t.Cleanup(func() {
+		keyStore, authEnabled = previousStore, previousEnabled
+	})
+}
+
+// auditApp собирает /api как в сервере и перехватывает очередь журнала аудита
+func auditApp(t *testing.T) (*fiber.App, chan *models.AuditEntry) {
+	useAPIKeys(t, "dashboard:viewer:viewer-key")
+
+	queue := make(chan *models.AuditEntry, 10)
+	auditWriter.Lock()
+	auditWriter.queue = queue
+	auditWriter.Unlock()
+
+	t.Cleanup(func() {
+		auditWriter.Lock()
+		auditWriter.queue = nil
+		auditWriter.Unlock()
+	})
+
+	app := fiber.New()
+	api := app.Group("/api")
+	api.Use(Audit)
+	api.Use(Authenticate)
+	api.Get("/jobs", RequireRole(auth.RoleViewer), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	api.Post("/jobs/:id/cancel", RequireRole(auth.RoleViewer), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	api.Post("/extend-keys", RequireRole(auth.RoleAdmin), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+
+	return app, queue
+}
+
+func TestAuditRecordsMutatingCalls(t *testing.T) {
+	app, queue := auditApp(t)
+
+	const body = `{"dry_run":true}`
+	largeBody := `{"names":["` + strings.Repeat("x", auditBodyLimit) + `"]}`
+
+	tests := []struct {
+		name         string
+		path         string
+		key          string
+		body         string
+		wantStatus   int
+		wantEndpoint string
+		wantIdentity string
+		wantOutcome  string
+		wantBody     interface{}
+	}{
+		{"без ключа", "/api/extend-keys", "", body, fiber.StatusUnauthorized, "/api/extend-keys", "-", models.AuditOutcomeRejected, nil},
+		{"неизвестный ключ", "/api/jobs/42/cancel", "wrong", body, fiber.StatusUnauthorized, "/api/jobs/42/cancel", "-", models.AuditOutcomeRejected, nil},
+		{"неизвестный маршрут", "/api/unknown", "viewer-key", body, fiber.StatusNotFound, "/api/unknown", "api_key:dashboard", models.AuditOutcomeRejected, nil},
+		{"нет роли", "/api/extend-keys", "viewer-key", body, fiber.StatusForbidden, "/api/extend-keys", "api_key:dashboard", models.AuditOutcomeRejected, map[string]interface{}{"dry_run": true}},
+		{"принят", "/api/jobs/42/cancel", "viewer-key", "", fiber.StatusOK, "/api/jobs/:id/cancel", "api_key:dashboard", models.AuditOutcomeSuccess, nil},
+		{"большое тело", "/api/jobs/42/cancel", "viewer-key", largeBody, fiber.StatusOK, "/api/jobs/:id/cancel", "api_key:dashboard", models.AuditOutcomeSuccess,
+			fmt.Sprintf("тело запроса %d байт не сохранено", len(largeBody))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.key != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.key)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("статус %d, ожидался %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			select {
+			case entry := <-queue:
+				if entry.Endpoint != tt.wantEndpoint || entry.Path != tt.path || entry.Identity != tt.wantIdentity ||
+					entry.Status != tt.wantStatus || entry.Outcome != tt.wantOutcome {
+					t.Errorf("запись аудита %+v", entry)
+				}
+				if !reflect.DeepEqual(entry.Params.Body, tt.wantBody) {
+					t.Errorf("тело в журнале %#v, ожидалось %#v", entry.Params.Body, tt.wantBody)
+				}
+				// Тело не ожидается - значит, не должно быть и остальных параметров
+				if tt.wantBody == nil && tt.body != "" && !reflect.DeepEqual(entry.Params, models.AuditParams{}) {
+					t.Errorf("параметры в журнале: %+v", entry.Params)
+				}
+			default:
+				t.Fatal("вызов не записан в журнал аудита")
+			}
+		})
+	}
+}
+
+func TestRecordAuditDropsWhenQueueFull(t *testing.T) {
+	queue := make(chan *models.AuditEntry, 1)
+	auditWriter.Lock()
+	auditWriter.queue = queue
+	auditWriter.Unlock()
+	t.Cleanup(func() {
+		auditWriter.Lock()
+		auditWriter.queue = nil
+		auditWriter.Unlock()
+	})
+
+	recordAudit(&models.AuditEntry{Method: fiber.MethodPost})
+	recordAudit(&models.AuditEntry{Method: fiber.MethodPost}) // не должна блокироваться
+
+	if len(queue) != 1 {
+		t.Errorf("в очереди %d записей, ожидалась 1", len(queue))
+	}
+}
+
+func TestAuditSkipsReads(t *testing.T) {
+	app, queue := auditApp(t)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/api/jobs", nil)
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+
+	select {
+	case entry := <-queue:
+		t.Errorf("GET не должен попадать в журнал аудита: %+v", entry)
+	default:
+	}
+}
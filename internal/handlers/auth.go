@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"minion/internal/auth"
+	"minion/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// identityLocal - ключ Locals, под которым лежит auth.Identity аутентифицированного запроса
+const identityLocal = "identity"
+
+// keyStore хранит хеши статических API ключей, jwtVerifier проверяет JWT (nil - JWT не принимаются);
+// authEnabled=false пропускает все запросы
+var (
+	keyStore    = auth.NewKeyStore()
+	jwtVerifier *auth.Verifier
+	authEnabled bool
+)
+
+// StartAuth загружает API ключи из API_KEYS и поля minion_api_keys секрета AWS и JWKS для проверки JWT
+func StartAuth() error {
+	envConfig := config.LoadEnvConfig()
+	authEnabled = envConfig.AuthEnabled
+	if !authEnabled {
+		log.Println("⚠️ Аутентификация API выключена (AUTH_ENABLED=false)")
+		return nil
+	}
+
+	keyStore = auth.NewKeyStore()
+	if err := keyStore.Add(envConfig.APIKeys); err != nil {
+		return fmt.Errorf("API_KEYS: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	secretKeys, err := config.SecretAPIKeys(ctx, envConfig)
+	if err != nil {
+		log.Printf("⚠️ Не удалось загрузить API ключи из AWS: %v", err)
+	} else if err := keyStore.Add(secretKeys); err != nil {
+		return fmt.Errorf("minion_api_keys в секрете %s: %w", envConfig.AWSSecretName, err)
+	}
+
+	if envConfig.JWTJWKS != "" {
+		roleMap, err := auth.ParseRoleMap(envConfig.JWTRoleMap)
+		if err != nil {
+			return fmt.Errorf("JWT_ROLE_MAP: %w", err)
+		}
+
+		// Недоступный при старте JWKS загрузится при первом запросе с JWT
+		jwks := auth.NewJWKS(envConfig.JWTJWKS, envConfig.JWTJWKSRefresh)
+		if err := jwks.Load(ctx); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+
+		jwtVerifier = auth.NewVerifier(jwks, auth.JWTConfig{
+			Issuer:        envConfig.JWTIssuer,
+			Audience:      envConfig.JWTAudience,
+			IdentityClaim: envConfig.JWTIdentityClaim,
+			RolesClaim:    envConfig.JWTRolesClaim,
+			RoleMap:       roleMap,
+		})
+	}
+
+	if keyStore.Len() == 0 && jwtVerifier == nil {
+		return errors.New("аутентификация включена, но не задан ни API ключ, ни JWT_JWKS: заполните API_KEYS или minion_api_keys в секрете AWS, либо выключите аутентификацию через AUTH_ENABLED=false")
+	}
+
+	log.Printf("🛡️ Аутентификация API: ключей %d, JWT: %t", keyStore.Len(), jwtVerifier != nil)
+	return nil
+}
+
+// Authenticate пропускает только запросы с известным ключом в заголовке Authorization: Bearer
+func Authenticate(c *fiber.Ctx) error {
+	if !authEnabled {
+		return c.Next()
+	}
+
+	token, ok := bearerToken(c)
+	if !ok {
+		return unauthorized(c, "требуется заголовок Authorization: Bearer <ключ или JWT>")
+	}
+
+	if jwtVerifier != nil && auth.LooksLikeJWT(token) {
+		identity, err := jwtVerifier.Verify(c.UserContext(), token)
+		if err != nil {
+			log.Printf("⛔ JWT отклонен (%s): %v", c.IP(), err)
+			return unauthorized(c, err.Error())
+		}
+
+		c.Locals(identityLocal, identity)
+		return c.Next()
+	}
+
+	identity, ok := keyStore.Lookup(token)
+	if !ok {
+		return unauthorized(c, "неизвестный API ключ")
+	}
+
+	c.Locals(identityLocal, identity)
+	return c.Next()
+}
+
+// RequireRole пропускает только клиентов с ролью role или выше; ставится после Authenticate
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if hasRole(c, role) {
+			return c.Next()
+		}
+		return forbidden(c, c.Method()+" "+c.Route().Path, role)
+	}
+}
+
+// hasRole проверяет, что у клиента запроса роль role или выше; без аутентификации разрешено все
+func hasRole(c *fiber.Ctx, role string) bool {
+	if !authEnabled {
+		return true
+	}
+
+	identity, _ := c.Locals(identityLocal).(auth.Identity)
+	return identity.HasRole(role)
+}
+
+// forbidden возвращает ответ 403: для action нужна роль role
+func forbidden(c *fiber.Ctx, action, role string) error {
+	identity, _ := c.Locals(identityLocal).(auth.Identity)
+
+	current := identity.Role
+	if current == "" {
+		current = "не назначена"
+	}
+
+	return c.Status(fiber.StatusForbidden).JSON(APIResponse{
+		Success: false,
+		Message: "Недостаточно прав",
+		Error:   fmt.Sprintf("%s требует роль %s, у %s роль %s", action, role, identity, current),
+		Data: fiber.Map{
+			"required_role": role,
+			"role":          identity.Role,
+		},
+	})
+}
+
+// RequestIdentity возвращает клиента запроса для логов; "-", если запрос не аутентифицирован
+func RequestIdentity(c *fiber.Ctx) string {
+	if identity, ok := c.Locals(identityLocal).(auth.Identity); ok {
+		return identity.String()
+	}
+	return "-"
+}
+
+// bearerToken извлекает токен из заголовка Authorization
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized возвращает ответ 401 в стандартном формате
+func unauthorized(c *fiber.Ctx, reason string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="minion"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(APIResponse{
+		Success: false,
+		Message: "Требуется аутентификация",
+		Error:   reason,
+	})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"minion/internal/database"
+	"minion/internal/jobs"
+	"minion/internal/keys"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Сколько запусков возвращает GET /api/runs по умолчанию и максимум
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 500
+)
+
+// withHistory сохраняет запуск в историю: при старте со статусом running и по завершении с результатом.
+// Ошибка записи истории не прерывает операцию
+func withHistory(operation string, trigger models.Trigger, params models.RunParams, run jobs.RunFunc) jobs.RunFunc {
+	return func(ctx context.Context, job *jobs.Job) (result *models.OperationResult, err error) {
+		record := &models.Run{
+			ID:        job.ID(),
+			Operation: operation,
+			Trigger:   trigger,
+			Params:    params,
+			Status:    jobs.StatusRunning,
+			StartedAt: time.Now(),
+		}
+		saveRun(record)
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("паника при выполнении задачи: %v", r)
+			}
+
+			finishedAt := time.Now()
+			record.FinishedAt = &finishedAt
+			record.Result = result
+			record.Status = jobs.FinalStatus(err)
+			if err != nil {
+				record.Error = err.Error()
+			}
+			saveRun(record)
+		}()
+
+		return run(ctx, job)
+	}
+}
+
+// saveRun записывает запуск в minion_runs; контекст задачи не используется, чтобы сохранить и отмененный запуск
+func saveRun(run *models.Run) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	runs, err := runStore(ctx)
+	if err != nil {
+		log.Printf("⚠️ Не удалось сохранить запуск %s в историю: %v", run.ID, err)
+		return
+	}
+
+	if err := runs.Save(ctx, run); err != nil {
+		log.Printf("⚠️ Не удалось сохранить запуск %s в историю: %v", run.ID, err)
+	}
+}
+
+// runParams формирует параметры запуска для истории
+func runParams(request OperationRequest, extension *keys.Extension) models.RunParams {
+	params := models.RunParams{
+		DryRun:   request.DryRun,
+		Selector: request.Restaurants,
+	}
+	if extension != nil {
+		params.Extension = extension.String()
+	}
+	return params
+}
+
+// GetRuns возвращает историю запусков с фильтрами operation, status, restaurant, from, to и limit
+func GetRuns(c *fiber.Ctx) error {
+	filter, err := parseRunFilter(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	history, err := runStore(c.UserContext())
+	if err != nil {
+		return historyUnavailable(c, err)
+	}
+
+	runs, err := history.Find(c.UserContext(), filter)
+	if err != nil {
+		return historyUnavailable(c, err)
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "📜 История запусков",
+		Data:    runs,
+	})
+}
+
+// GetRun возвращает запуск с результатами по каждому ресторану
+func GetRun(c *fiber.Ctx) error {
+	runs, err := runStore(c.UserContext())
+	if err != nil {
+		return historyUnavailable(c, err)
+	}
+
+	run, err := runs.Get(c.UserContext(), c.Params("id"))
+	if errors.Is(err, database.ErrRunNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(APIResponse{
+			Success: false,
+			Message: "Запуск не найден",
+			Error:   fmt.Sprintf("запуск %s не найден", c.Params("id")),
+		})
+	}
+	if err != nil {
+		return historyUnavailable(c, err)
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "📜 Запуск",
+		Data:    run,
+	})
+}
+
+// parseRunFilter разбирает query параметры GET /api/runs
+func parseRunFilter(c *fiber.Ctx) (models.RunFilter, error) {
+	filter := models.RunFilter{
+		Operation:  c.Query("operation"),
+		Status:     c.Query("status"),
+		Restaurant: strings.TrimSpace(c.Query("restaurant")),
+		Limit:      defaultRunsLimit,
+	}
+
+	if filter.Operation != "" && filter.Operation != models.OperationExtendKeys && filter.Operation != models.OperationRefreshMenus {
+		return filter, fmt.Errorf("неизвестная операция: %s", filter.Operation)
+	}
+
+	switch filter.Status {
+	case "", jobs.StatusRunning, jobs.StatusCompleted, jobs.StatusFailed, jobs.StatusCancelled:
+	default:
+		return filter, fmt.Errorf("неизвестный статус: %s", filter.Status)
+	}
+
+	var err error
+	if filter.From, err = parseRunTime(c.Query("from"), false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseRunTime(c.Query("to"), true); err != nil {
+		return filter, err
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 || limit > maxRunsLimit {
+			return filter, fmt.Errorf("limit должен быть числом от 1 до %d", maxRunsLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// parseRunTime разбирает время RFC3339 или дату; дата в to включает весь день
+func parseRunTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	date, err := keys.ParseDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
+
+// historyUnavailable возвращает ответ 503, если история запусков недоступна
+func historyUnavailable(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+		Success: false,
+		Message: "История запусков недоступна",
+		Error:   err.Error(),
+	})
+}
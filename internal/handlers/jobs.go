@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"minion/internal/jobs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// jobManager хранит асинхронные задачи продления ключей, обновления меню и отчета по ключам
+var jobManager = jobs.NewManager()
+
+// GetJobs возвращает список задач
+func GetJobs(c *fiber.Ctx) error {
+	jobList := jobManager.List()
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "📋 Список задач",
+		Data:    jobList,
+	})
+}
+
+// GetJob возвращает статус, прогресс и результат задачи
+func GetJob(c *fiber.Ctx) error {
+	job, ok := jobManager.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(APIResponse{
+			Success: false,
+			Message: "Задача не найдена",
+			Error:   fmt.Sprintf("задача %s не найдена", c.Params("id")),
+		})
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "📋 Статус задачи",
+		Data:    job.Info(),
+	})
+}
+
+// CancelJob отменяет выполняющуюся задачу
+func CancelJob(c *fiber.Ctx) error {
+	job, ok := jobManager.Get(c.Params("id"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(APIResponse{
+			Success: false,
+			Message: "Задача не найдена",
+			Error:   fmt.Sprintf("задача %s не найдена", c.Params("id")),
+		})
+	}
+
+	if !jobManager.Cancel(job.ID()) {
+		return c.Status(fiber.StatusConflict).JSON(APIResponse{
+			Success: false,
+			Message: "Задача уже завершена",
+			Error:   fmt.Sprintf("задача %s уже завершена", job.ID()),
+		})
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "🛑 Задача отменяется",
+		Data:    jobAccepted(job),
+	})
+}
+
+// shutdownTimeout - сколько ждать завершения отмененных задач при остановке сервера
+const shutdownTimeout = 30 * time.Second
+
+// Shutdown останавливает планировщик, мониторинг и Telegram бота, отменяет выполняющиеся задачи
+// и ждет их завершения, а затем доставки отправленных оповещений.
+// Оповещения, журнал аудита и подключение к MongoDB закрываются последними: завершающиеся задачи
+// еще отправляют итоги запусков и освобождают блокировки, а очередь аудита дописывается в базу
+func Shutdown() {
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	if keyMonitor != nil {
+		keyMonitor.Stop()
+	}
+	if telegramBot != nil {
+		telegramBot.Stop()
+	}
+	if !jobManager.Shutdown(shutdownTimeout) {
+		log.Printf("⚠️ Не все задачи завершились за %s, останавливаемся без них", shutdownTimeout)
+	}
+	notifier.Close()
+	stopAudit(shutdownTimeout)
+	closeStorage()
+}
+
+// jobAccepted формирует ответ о принятой задаче
+func jobAccepted(job *jobs.Job) fiber.Map {
+	return fiber.Map{
+		"job_id":     job.ID(),
+		"status_url": "/api/jobs/" + job.ID(),
+	}
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"minion/internal/auth"
+	"minion/internal/client"
+	"minion/internal/config"
+	"minion/internal/jobs"
+	"minion/internal/keys"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// keyReports хранит последний полный отчет по ключам. Его обновляют мониторинг ключей
+// и задача key-report, которую запускает GET /api/keys, если отчета еще нет или передан refresh=true.
+// Отчет сохраняется и в MongoDB, чтобы реплики без блокировки мониторинга не читали iiko сами
+var keyReports = struct {
+	sync.Mutex
+	report *models.KeyReport
+	job    *jobs.Job // выполняющееся обновление отчета
+}{}
+
+// GetKeys возвращает отчет о сроках действия API логинов ресторанов в JSON или CSV (?format=csv).
+// Отчет берется из последней полной проверки; iiko читается только фоновой задачей, ответ на запуск которой - 202
+func GetKeys(c *fiber.Ctx) error {
+	selector, err := parseSelectorQuery(c)
+	if err != nil {
+		return badRequest(c, err)
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" && strings.Contains(c.Get(fiber.HeaderAccept), "text/csv") {
+		format = "csv"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		return badRequest(c, fmt.Errorf("неизвестный формат %s, ожидается json или csv", format))
+	}
+
+	// Обновление читает iiko по всем ресторанам, поэтому доступно только операторам
+	refresh := c.QueryBool("refresh")
+	if refresh && !hasRole(c, auth.RoleOperator) {
+		return forbidden(c, "GET /api/keys?refresh=true", auth.RoleOperator)
+	}
+
+	log.Printf("🔑 API запрос: отчет по ключам для %s от %s", selector, c.IP())
+
+	report := cachedKeyReport()
+	if report == nil {
+		report = loadStoredKeyReport(c.UserContext())
+	}
+	if report == nil || refresh {
+		job := refreshKeyReport()
+		return c.Status(fiber.StatusAccepted).JSON(APIResponse{
+			Success: true,
+			Message: "🔄 Отчет по ключам формируется, повторите запрос после завершения задачи",
+			Data:    jobAccepted(job),
+		})
+	}
+
+	report, err = selectKeyReport(c.UserContext(), report, selector)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+			Success: false,
+			Message: "Не удалось сформировать отчет по ключам",
+			Error:   err.Error(),
+		})
+	}
+
+	if format == "csv" {
+		body, err := keyReportCSV(report)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="keys-%s.csv"`, report.GeneratedAt.Format("2006-01-02")))
+		return c.Send(body)
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("🔑 Ключей: %d, ресторанов с ошибками: %d", len(report.Keys), len(report.Errors)),
+		Data:    report,
+	})
+}
+
+// cachedKeyReport возвращает последний полный отчет по ключам; nil, если его еще нет
+func cachedKeyReport() *models.KeyReport {
+	keyReports.Lock()
+	defer keyReports.Unlock()
+	return keyReports.report
+}
+
+// storeKeyReport сохраняет полный отчет по ключам для GET /api/keys этой реплики и в MongoDB для остальных
+func storeKeyReport(report *models.KeyReport) {
+	keyReports.Lock()
+	keyReports.report = report
+	keyReports.Unlock()
+
+	// Контекст проверки к этому моменту может быть почти исчерпан
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	keyReportService, err := keyReportStore(ctx)
+	if err == nil {
+		err = keyReportService.Save(ctx, report)
+	}
+	if err != nil {
+		log.Printf("⚠️ Отчет по ключам не сохранен в MongoDB: %v", err)
+	}
+}
+
+// loadStoredKeyReport берет из MongoDB отчет, сохраненный другой репликой, если он новее отчета в памяти.
+// Возвращает самый новый отчет; nil, если отчета нет ни в памяти, ни в базе
+func loadStoredKeyReport(ctx context.Context) *models.KeyReport {
+	keyReportService, err := keyReportStore(ctx)
+	var stored *models.KeyReport
+	if err == nil {
+		stored, err = keyReportService.Latest(ctx)
+	}
+	if err != nil {
+		log.Printf("⚠️ Не удалось прочитать отчет по ключам из MongoDB: %v", err)
+	}
+
+	keyReports.Lock()
+	defer keyReports.Unlock()
+
+	if stored != nil && (keyReports.report == nil || stored.GeneratedAt.After(keyReports.report.GeneratedAt)) {
+		keyReports.report = stored
+	}
+	return keyReports.report
+}
+
+// refreshKeyReport запускает задачу обновления отчета по ключам.
+// Если обновление уже идет, возвращает его задачу, чтобы не читать iiko дважды
+func refreshKeyReport() *jobs.Job {
+	keyReports.Lock()
+	defer keyReports.Unlock()
+
+	if keyReports.job != nil {
+		return keyReports.job
+	}
+
+	keyReports.job = jobManager.Submit(models.OperationKeyReport, func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		defer func() {
+			keyReports.Lock()
+			keyReports.job = nil
+			keyReports.Unlock()
+		}()
+
+		envConfig := config.LoadEnvConfig()
+		ctx, cancel := context.WithTimeout(ctx, envConfig.RunTimeout)
+		defer cancel()
+
+		report, err := collectKeyReport(ctx, envConfig, models.RestaurantSelector{})
+		if err != nil {
+			return nil, err
+		}
+		// Отчет, прерванный отменой или таймаутом, неполный - оставляем прежний
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("отчет прерван: %w", err)
+		}
+
+		storeKeyReport(report)
+		observeKeyReport(report)
+		log.Printf("🔑 Отчет по ключам обновлен: ключей %d, ресторанов с ошибками %d", len(report.Keys), len(report.Errors))
+		return nil, nil
+	})
+
+	return keyReports.job
+}
+
+// selectKeyReport оставляет в полном отчете только рестораны селектора
+func selectKeyReport(ctx context.Context, report *models.KeyReport, selector models.RestaurantSelector) (*models.KeyReport, error) {
+	if selector.IsEmpty() {
+		return report, nil
+	}
+
+	restaurants, err := loadRestaurants(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки ресторанов: %w", err)
+	}
+
+	selected := map[string]bool{}
+	for _, restaurant := range restaurants {
+		if restaurant.Enabled {
+			selected[restaurant.ID] = true
+		}
+	}
+
+	filtered := &models.KeyReport{
+		GeneratedAt: report.GeneratedAt,
+		Selector:    selector.String(),
+		Restaurants: len(selected),
+		Keys:        []models.KeyReportEntry{},
+	}
+	for _, entry := range report.Keys {
+		if selected[entry.RestaurantID] {
+			filtered.Keys = append(filtered.Keys, entry)
+		}
+	}
+	for _, reportError := range report.Errors {
+		if selected[reportError.RestaurantID] {
+			filtered.Errors = append(filtered.Errors, reportError)
+		}
+	}
+	return filtered, nil
+}
+
+// collectKeyReport параллельно читает API логины включенных ресторанов и сортирует их по ближайшему истечению
+func collectKeyReport(ctx context.Context, envConfig *config.EnvConfig, selector models.RestaurantSelector) (*models.KeyReport, error) {
+	restaurants, err := loadRestaurants(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки ресторанов: %w", err)
+	}
+
+	var enabled []*models.Restaurant
+	for _, restaurant := range restaurants {
+		if restaurant.Enabled {
+			enabled = append(enabled, restaurant)
+		}
+	}
+
+	report := &models.KeyReport{
+		GeneratedAt: time.Now(),
+		Selector:    selector.String(),
+		Restaurants: len(enabled),
+		Keys:        []models.KeyReportEntry{},
+	}
+
+	retryPolicy := newRetryPolicy(envConfig)
+
+	var mu sync.Mutex
+	pool := jobs.NewPool(envConfig.Concurrency, envConfig.DomainConcurrency)
+	pool.Run(len(enabled), func(i int) string {
+		return restaurantDomain(enabled[i])
+	}, func(i int) {
+		restaurant := *enabled[i]
+		entries, err := collectRestaurantKeys(ctx, restaurant, retryPolicy)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			log.Printf("❌ Отчет по ключам: ресторан %s: %v", restaurant.Name, err)
+			report.Errors = append(report.Errors, models.KeyReportError{
+				RestaurantID: restaurant.ID,
+				Restaurant:   restaurant.Name,
+				Error:        err.Error(),
+				ErrorCode:    client.ErrorCode(err),
+			})
+			return
+		}
+		report.Keys = append(report.Keys, entries...)
+	})
+
+	sortKeyReport(report)
+
+	return report, nil
+}
+
+// collectRestaurantKeys читает все API логины ресторана с деталями
+func collectRestaurantKeys(ctx context.Context, restaurant models.Restaurant, retryPolicy client.RetryPolicy) (entries []models.KeyReportEntry, err error) {
+	// Паника в воркере уронила бы весь процесс
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника: %v", r)
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("отчет прерван: %w", err)
+	}
+
+	apiClient := client.NewIikoClient(restaurant.BaseURL, restaurant.Login, restaurant.Password)
+	apiClient.SetRetryPolicy(retryPolicy)
+
+	if err := apiClient.Login(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка авторизации: %w", err)
+	}
+
+	response, err := apiClient.GetApiLogins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения API логинов: %w", err)
+	}
+
+	for _, apiLogin := range response.ApiLogins {
+		entry := models.KeyReportEntry{
+			RestaurantID:   restaurant.ID,
+			Restaurant:     restaurant.Name,
+			ApiLoginID:     apiLogin.ID,
+			ApiLogin:       apiLogin.Name,
+			ExpirationDate: apiLogin.ExpirationDate,
+			IsActive:       apiLogin.IsActive,
+			ExternalMenus:  apiLogin.ExternalMenus,
+			RestaurantMenu: restaurant.IikoExternalMenuId != "" && hasExternalMenu(apiLogin, restaurant.IikoExternalMenuId),
+		}
+		if entry.ExternalMenus == nil {
+			entry.ExternalMenus = []models.ExternalMenu{}
+		}
+
+		// Признак бессрочности есть только в деталях логина. Без них бессрочный логин
+		// выглядел бы истекающим по дате из списка, поэтому ресторан уходит в ошибки
+		detailResponse, err := apiClient.GetApiLoginDetail(ctx, apiLogin.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения деталей логина %s: %w", apiLogin.Name, err)
+		}
+		detail := detailResponse.ApiLoginInfo
+		entry.IsLongLived = detail.IsLongLived
+		if detail.ExpirationDate != nil {
+			entry.ExpirationDate = *detail.ExpirationDate
+		}
+
+		if !entry.IsLongLived && entry.ExpirationDate != "" {
+			if expiration, err := time.Parse(keys.DateLayout, entry.ExpirationDate); err == nil {
+				days := keys.DaysUntil(expiration)
+				entry.DaysRemaining = &days
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// sortKeyReport сортирует ключи по ближайшему истечению; бессрочные и без даты - в конце
+func sortKeyReport(report *models.KeyReport) {
+	sort.SliceStable(report.Keys, func(i, k int) bool {
+		a, b := report.Keys[i], report.Keys[k]
+		switch {
+		case a.DaysRemaining != nil && b.DaysRemaining == nil:
+			return true
+		case a.DaysRemaining == nil && b.DaysRemaining != nil:
+			return false
+		case a.DaysRemaining != nil && *a.DaysRemaining != *b.DaysRemaining:
+			return *a.DaysRemaining < *b.DaysRemaining
+		case a.Restaurant != b.Restaurant:
+			return a.Restaurant < b.Restaurant
+		}
+		return a.ApiLogin < b.ApiLogin
+	})
+
+	sort.SliceStable(report.Errors, func(i, k int) bool {
+		return report.Errors[i].Restaurant < report.Errors[k].Restaurant
+	})
+}
+
+// keyReportCSV формирует CSV отчета; рестораны с ошибками идут отдельными строками с заполненной колонкой error
+func keyReportCSV(report *models.KeyReport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{{
+		"restaurant_id", "restaurant", "api_login_id", "api_login", "expiration_date", "days_remaining",
+		"is_active", "is_long_lived", "external_menus", "restaurant_menu", "error",
+	}}
+	for _, entry := range report.Keys {
+		days := ""
+		if entry.DaysRemaining != nil {
+			days = strconv.Itoa(*entry.DaysRemaining)
+		}
+
+		menus := make([]string, 0, len(entry.ExternalMenus))
+		for _, menu := range entry.ExternalMenus {
+			menus = append(menus, menu.Name)
+		}
+
+		rows = append(rows, []string{
+			entry.RestaurantID, entry.Restaurant, entry.ApiLoginID, entry.ApiLogin, entry.ExpirationDate, days,
+			strconv.FormatBool(entry.IsActive), strconv.FormatBool(entry.IsLongLived), strings.Join(menus, "; "),
+			strconv.FormatBool(entry.RestaurantMenu), "",
+		})
+	}
+	for _, reportError := range report.Errors {
+		rows = append(rows, []string{
+			reportError.RestaurantID, reportError.Restaurant, "", "", "", "", "", "", "", "", reportError.Error,
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("ошибка формирования CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"minion/internal/auth"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetKeysRefreshRequiresOperator(t *testing.T) {
+	useAPIKeys(t, "dashboard:viewer:viewer-key")
+
+	// Отчет только в памяти: запрос не должен обращаться ни к MongoDB, ни к iiko
+	keyReports.Lock()
+	previous := keyReports.report
+	keyReports.report = &models.KeyReport{GeneratedAt: time.Now(), Keys: []models.KeyReportEntry{}}
+	keyReports.Unlock()
+	t.Cleanup(func() {
+		keyReports.Lock()
+		keyReports.report = previous
+		keyReports.Unlock()
+	})
+
+	app := fiber.New()
+	app.Get("/api/keys", Authenticate, RequireRole(auth.RoleViewer), GetKeys)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"отчет из памяти", "/api/keys", fiber.StatusOK},
+		{"обновление без роли operator", "/api/keys?refresh=true", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer viewer-key")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
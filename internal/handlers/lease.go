@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"minion/internal/config"
+	"minion/internal/database"
+	"minion/internal/jobs"
+	"minion/internal/models"
+)
+
+// acquireOperationLease захватывает общую для реплик блокировку операции.
+// Dry run ничего не меняет в iiko и выполняется без блокировки (nil)
+func acquireOperationLease(operation string, dryRun bool) (*database.Lease, error) {
+	if dryRun {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leases, err := leaseStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return leases.Acquire(ctx, operation, config.LoadEnvConfig().LeaseTTL)
+}
+
+// withLease держит блокировку, пока выполняется задача, и освобождает ее по завершении
+func withLease(lease *database.Lease, run jobs.RunFunc) jobs.RunFunc {
+	if lease == nil {
+		return run
+	}
+
+	return func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		ctx, release := lease.Hold(ctx)
+		defer release()
+
+		if err := lease.SetJobID(ctx, job.ID()); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+
+		return run(ctx, job)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"minion/internal/client"
+	"minion/internal/jobs"
+	"minion/internal/keys"
+	"minion/internal/metrics"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Метрики HTTP API, запусков и ресторанов; метрики iiko объявлены в пакете client
+var (
+	httpRequests = metrics.Default.NewCounter("minion_http_requests_total",
+		"Количество HTTP запросов по маршруту и статусу", "method", "route", "status")
+	httpDuration = metrics.Default.NewHistogram("minion_http_request_duration_seconds",
+		"Длительность обработки HTTP запросов", metrics.DefaultBuckets, "method", "route")
+
+	runsTotal = metrics.Default.NewCounter("minion_runs_total",
+		"Количество завершенных запусков по операции и статусу", "operation", "status")
+	runDuration = metrics.Default.NewHistogram("minion_run_duration_seconds",
+		"Длительность запусков по операции", metrics.RunBuckets, "operation")
+
+	restaurantResults = metrics.Default.NewCounter("minion_restaurant_results_total",
+		"Результаты обработки ресторанов: success, partial, failed (без dry run и отмененных)",
+		"operation", "restaurant_id", "restaurant", "status")
+
+	keyExpiryDays = metrics.Default.NewGaugeFunc("minion_key_expiry_days",
+		"Дней до ближайшего истечения активных API ключей меню ресторана; отрицательное - ключ уже истек",
+		[]string{"restaurant_id", "restaurant"}, keyExpirySamples)
+)
+
+// keyExpiry хранит ближайшую дату истечения ключей по ресторанам для keyExpiryDays;
+// дни считаются при каждом чтении метрик, поэтому значение не устаревает между проверками
+var keyExpiry = struct {
+	sync.Mutex
+	restaurants map[string]keyExpiryEntry
+}{restaurants: map[string]keyExpiryEntry{}}
+
+type keyExpiryEntry struct {
+	restaurant string
+	expiresAt  time.Time
+}
+
+// Metrics считает запросы и их длительность по маршруту, а не по пути,
+// чтобы id в пути не порождали новые серии
+func Metrics(c *fiber.Ctx) error {
+	startedAt := time.Now()
+	err := c.Next()
+
+	method := c.Method()
+	route := c.Route().Path
+	httpRequests.Inc(method, route, strconv.Itoa(responseStatus(c, err)))
+	httpDuration.Observe(time.Since(startedAt).Seconds(), method, route)
+
+	return err
+}
+
+// responseStatus возвращает статус ответа; ошибку обработчика в ответ
+// превратит ErrorHandler сервера уже после middleware
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// GetMetrics отдает метрики в текстовом формате Prometheus
+func GetMetrics(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	_, err := metrics.Default.WriteTo(c)
+	return err
+}
+
+// withMetrics считает запуск, его длительность и результаты по ресторанам
+func withMetrics(operation string, params models.RunParams, run jobs.RunFunc) jobs.RunFunc {
+	return func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		startedAt := time.Now()
+		result, err := run(ctx, job)
+
+		runsTotal.Inc(operation, jobs.FinalStatus(err))
+		runDuration.Observe(time.Since(startedAt).Seconds(), operation)
+
+		if result != nil && !params.DryRun {
+			observeRestaurants(operation, result)
+		}
+
+		return result, err
+	}
+}
+
+// observeRestaurants считает результаты ресторанов и обновляет сроки ключей после продления.
+// Рестораны, до которых запуск не дошел из-за отмены, не считаются
+func observeRestaurants(operation string, result *models.OperationResult) {
+	for _, detail := range result.Details {
+		if detail.ErrorCode == client.ErrorCodeCancelled {
+			continue
+		}
+		restaurantResults.Inc(operation, detail.ID, detail.Name, detail.Status)
+
+		if operation != models.OperationExtendKeys || detail.Status == models.RestaurantStatusFailed {
+			continue
+		}
+		if expiresAt := soonestExpiration(detail.ApiLogins); expiresAt != nil {
+			setKeyExpiry(detail.ID, detail.Name, *expiresAt)
+		}
+	}
+}
+
+// setKeyExpiry запоминает ближайшую дату истечения ключей ресторана
+func setKeyExpiry(restaurantID, restaurant string, expiresAt time.Time) {
+	keyExpiry.Lock()
+	defer keyExpiry.Unlock()
+	keyExpiry.restaurants[restaurantID] = keyExpiryEntry{restaurant: restaurant, expiresAt: expiresAt}
+}
+
+// observeKeyReport заменяет сроки ключей данными полной проверки мониторинга.
+// Рестораны, в которых не удалось прочитать ключи, сохраняют прежнее значение
+func observeKeyReport(report *models.KeyReport) {
+	soonest := map[string]keyExpiryEntry{}
+	for _, entry := range report.Keys {
+		if !entry.IsActive || !entry.RestaurantMenu || entry.DaysRemaining == nil {
+			continue
+		}
+		expiresAt, err := time.Parse(keys.DateLayout, entry.ExpirationDate)
+		if err != nil {
+			continue
+		}
+		if current, ok := soonest[entry.RestaurantID]; !ok || expiresAt.Before(current.expiresAt) {
+			soonest[entry.RestaurantID] = keyExpiryEntry{restaurant: entry.Restaurant, expiresAt: expiresAt}
+		}
+	}
+
+	keyExpiry.Lock()
+	defer keyExpiry.Unlock()
+
+	for _, reportError := range report.Errors {
+		if previous, ok := keyExpiry.restaurants[reportError.RestaurantID]; ok {
+			soonest[reportError.RestaurantID] = previous
+		}
+	}
+	keyExpiry.restaurants = soonest
+}
+
+// keyExpirySamples считает дни до истечения ключей на момент чтения метрик
+func keyExpirySamples() []metrics.Sample {
+	keyExpiry.Lock()
+	defer keyExpiry.Unlock()
+
+	samples := make([]metrics.Sample, 0, len(keyExpiry.restaurants))
+	for restaurantID, entry := range keyExpiry.restaurants {
+		samples = append(samples, metrics.Sample{
+			Labels: []string{restaurantID, entry.restaurant},
+			Value:  float64(keys.DaysUntil(entry.expiresAt)),
+		})
+	}
+	return samples
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"minion/internal/config"
+	"minion/internal/database"
+	"minion/internal/models"
+	"minion/internal/monitor"
+	"minion/internal/notify"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// monitorLease - блокировка проверки ключей, чтобы ключи проверяла одна реплика
+const monitorLease = "key-monitor"
+
+// keyMonitor периодически проверяет сроки действия ключей; nil, если мониторинг выключен
+var keyMonitor *monitor.Monitor
+
+// StartMonitor запускает мониторинг сроков действия ключей, если задан MONITOR_INTERVAL
+func StartMonitor() error {
+	envConfig := config.LoadEnvConfig()
+	if envConfig.MonitorInterval <= 0 {
+		log.Println("🔕 Мониторинг ключей выключен")
+		return nil
+	}
+
+	thresholds, err := monitor.ParseThresholds(envConfig.MonitorThresholds)
+	if err != nil {
+		return fmt.Errorf("MONITOR_THRESHOLDS: %w", err)
+	}
+
+	keyMonitor = monitor.NewMonitor(envConfig.MonitorInterval, func(ctx context.Context) error {
+		return checkKeys(ctx, thresholds)
+	})
+	keyMonitor.Start()
+
+	log.Printf("👀 Мониторинг ключей: каждые %s, пороги %v дн.", envConfig.MonitorInterval, thresholds)
+	return nil
+}
+
+// checkKeys читает сроки действия ключей, поднимает предупреждения по пересеченным порогам
+// и снимает предупреждения по ключам, которые были продлены
+func checkKeys(ctx context.Context, thresholds []int) error {
+	envConfig := config.LoadEnvConfig()
+
+	lease, err := acquireOperationLease(monitorLease, false)
+	var heldErr *database.LeaseHeldError
+	if errors.As(err, &heldErr) {
+		// Ключи проверяет другая реплика: берем ее отчет, чтобы GET /api/keys не запускал свой обход iiko
+		log.Printf("⏭️  Мониторинг ключей: %v, берем сохраненный отчет", err)
+		if report := loadStoredKeyReport(ctx); report != nil {
+			observeKeyReport(report)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ctx, release := lease.Hold(ctx)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, envConfig.RunTimeout)
+	defer cancel()
+
+	report, err := collectKeyReport(ctx, envConfig, models.RestaurantSelector{})
+	if err != nil {
+		return err
+	}
+	// Прерванный отчет неполный: по нему нельзя снимать предупреждения и отдавать его в GET /api/keys
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("проверка прервана: %w", err)
+	}
+	observeKeyReport(report)
+	storeKeyReport(report)
+	alerts := monitor.Evaluate(report, thresholds, time.Now())
+
+	alertService, err := alertStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	keep := make([]string, 0, len(alerts))
+	var raised []models.KeyAlert
+	for _, alert := range alerts {
+		keep = append(keep, alert.ID)
+
+		isNew, err := alertService.Raise(ctx, alert)
+		if err != nil {
+			return err
+		}
+		if isNew {
+			raised = append(raised, alert)
+		}
+	}
+
+	// Рестораны, в которых не удалось прочитать ключи, не считаем продленными
+	skipRestaurants := make([]string, 0, len(report.Errors))
+	for _, reportError := range report.Errors {
+		skipRestaurants = append(skipRestaurants, reportError.RestaurantID)
+	}
+	cleared, err := alertService.Clear(ctx, keep, skipRestaurants)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range mostSevere(raised) {
+		log.Printf("🚨 Ключ %s ресторана %s истекает %s: осталось %d дн. (порог %d дн.)",
+			alert.ApiLogin, alert.Restaurant, alert.ExpirationDate, alert.DaysRemaining, alert.Threshold)
+		notifier.Dispatch(notify.KeyExpiring(alert))
+	}
+	for _, alert := range cleared {
+		log.Printf("✅ Предупреждение снято: ключ %s ресторана %s (порог %d дн.)", alert.ApiLogin, alert.Restaurant, alert.Threshold)
+	}
+
+	log.Printf("👀 Мониторинг ключей: проверено %d ключей, активных предупреждений %d, новых %d, снято %d, ресторанов с ошибками %d",
+		len(report.Keys), len(alerts), len(raised), len(cleared), len(report.Errors))
+
+	return nil
+}
+
+// mostSevere оставляет для каждого логина только предупреждение с наименьшим порогом:
+// если ключ сразу пересек 30 и 7 дней, достаточно одного оповещения о 7 днях
+func mostSevere(alerts []models.KeyAlert) []models.KeyAlert {
+	index := map[string]int{}
+	var result []models.KeyAlert
+	for _, alert := range alerts {
+		key := alert.RestaurantID + ":" + alert.ApiLoginID
+		if i, ok := index[key]; ok {
+			if alert.Threshold < result[i].Threshold {
+				result[i] = alert
+			}
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, alert)
+	}
+	return result
+}
+
+// GetAlerts возвращает состояние мониторинга и активные предупреждения об истекающих ключах
+func GetAlerts(c *fiber.Ctx) error {
+	envConfig := config.LoadEnvConfig()
+
+	alertService, err := alertStore(c.UserContext())
+	if err != nil {
+		return alertsUnavailable(c, err)
+	}
+
+	alerts, err := alertService.List(c.UserContext())
+	if err != nil {
+		return alertsUnavailable(c, err)
+	}
+
+	data := fiber.Map{
+		"enabled":    keyMonitor != nil,
+		"thresholds": envConfig.MonitorThresholds,
+		"alerts":     alerts,
+	}
+	if keyMonitor != nil {
+		data["monitor"] = keyMonitor.Status()
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("🚨 Активных предупреждений: %d", len(alerts)),
+		Data:    data,
+	})
+}
+
+// alertsUnavailable возвращает ответ 503, если предупреждения недоступны
+func alertsUnavailable(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+		Success: false,
+		Message: "Предупреждения недоступны",
+		Error:   err.Error(),
+	})
+}
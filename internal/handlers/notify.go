@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"minion/internal/client"
+	"minion/internal/config"
+	"minion/internal/jobs"
+	"minion/internal/models"
+	"minion/internal/notify"
+)
+
+// notifier рассылает события запусков и мониторинга; без настроенных каналов события никуда не уходят
+var notifier *notify.Router
+
+// StartNotifier подключает каналы оповещений из конфигурации
+func StartNotifier() error {
+	envConfig := config.LoadEnvConfig()
+	notifier = notify.NewRouter(envConfig.NotifyTimeout)
+
+	for _, webhook := range envConfig.NotifyWebhooks {
+		events, err := notify.ParseEvents(webhook.Events)
+		if err != nil {
+			return err
+		}
+		notifier.Add(notify.NewWebhook(webhook.Name, webhook.URL, webhook.Secret, envConfig.NotifyMaxAttempts), events)
+		log.Printf("📣 Webhook %s: %v", webhook.Name, events)
+	}
+
+	if envConfig.WhatsAppGatewayURL != "" {
+		events, err := notify.ParseEvents(envConfig.WhatsAppEvents)
+		if err != nil {
+			return err
+		}
+		notifier.Add(notify.NewWhatsApp(envConfig.WhatsAppGatewayURL, envConfig.WhatsAppGatewayToken, envConfig.NotifyMaxAttempts,
+			envConfig.WhatsAppChatLimit, envConfig.WhatsAppChatWindow, restaurantContacts), events)
+		log.Printf("💬 WhatsApp оповещения ресторанов: %v", events)
+	}
+
+	return nil
+}
+
+// withNotifications оповещает о завершении запуска и о ресторанах, обработанных с ошибками
+func withNotifications(operation string, trigger models.Trigger, params models.RunParams, run jobs.RunFunc) jobs.RunFunc {
+	return func(ctx context.Context, job *jobs.Job) (*models.OperationResult, error) {
+		startedAt := time.Now()
+		result, err := run(ctx, job)
+
+		finishedAt := time.Now()
+		record := &models.Run{
+			ID:         job.ID(),
+			Operation:  operation,
+			Trigger:    trigger,
+			Params:     params,
+			Status:     jobs.FinalStatus(err),
+			StartedAt:  startedAt,
+			FinishedAt: &finishedAt,
+			Result:     result,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		notifyRun(record)
+
+		return result, err
+	}
+}
+
+// notifyRun отправляет событие о завершении запуска и по событию на каждый неуспешный ресторан.
+// Об ошибках dry run рестораны не оповещаются: в iiko ничего не менялось
+func notifyRun(run *models.Run) {
+	notifier.Dispatch(notify.RunCompleted(run))
+
+	if run.Result == nil || run.Params.DryRun {
+		return
+	}
+	for _, detail := range run.Result.Details {
+		if detail.Status == models.RestaurantStatusSuccess || detail.ErrorCode == client.ErrorCodeCancelled {
+			continue
+		}
+		notifier.Dispatch(notify.RestaurantFailed(run, detail))
+	}
+}
+
+// restaurantContacts загружает настройки WhatsApp оповещений всех ресторанов
+func restaurantContacts(ctx context.Context) (map[string]notify.Contact, error) {
+	restaurants, err := findRestaurants(ctx, models.RestaurantSelector{})
+	if err != nil {
+		return nil, err
+	}
+
+	contacts := make(map[string]notify.Contact, len(restaurants))
+	for _, restaurant := range restaurants {
+		contacts[restaurant.ID] = notify.Contact{
+			Restaurant: restaurant.Name,
+			Enabled:    restaurant.SendWhatsappNotification,
+			ChatID:     restaurant.WhatsappChatID,
+			Phone:      restaurant.StorePhoneNumber,
+		}
+	}
+	return contacts, nil
+}
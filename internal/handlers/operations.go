@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"minion/internal/client"
+	"minion/internal/config"
+	"minion/internal/jobs"
+	"minion/internal/keys"
+	"minion/internal/models"
+)
+
+// runExtendKeys выполняет продление ключей в рамках задачи
+func runExtendKeys(ctx context.Context, job *jobs.Job, request OperationRequest, extension keys.Extension) (*models.OperationResult, error) {
+	envConfig := config.LoadEnvConfig()
+
+	// Общий дедлайн на весь запуск
+	ctx, cancel := context.WithTimeout(ctx, envConfig.RunTimeout)
+	defer cancel()
+
+	// Загружаем рестораны
+	restaurants, err := loadRestaurants(ctx, request.Restaurants)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки ресторанов: %w", err)
+	}
+
+	defaultPolicy := envConfig.DefaultKeyPolicy()
+	log.Printf("🗓️ Политика продления ключей: %s", defaultPolicy)
+
+	result := runOperation(ctx, job, envConfig, restaurants, operation{
+		unit:     "ключей",
+		dryRun:   request.DryRun,
+		selector: request.Restaurants,
+		process: func(ctx context.Context, apiClient *client.IikoClient, restaurant models.Restaurant, restaurantResult *models.RestaurantResult) error {
+			policy := defaultPolicy.WithOverride(restaurant.KeyThresholdDays, restaurant.KeyHorizonDays)
+			if err := policy.Validate(); err != nil {
+				return fmt.Errorf("некорректная политика продления ресторана: %w", err)
+			}
+
+			// Явно заданные в запросе параметры продления важнее горизонта политики
+			restaurantExtension := extension
+			if !request.hasKeyExtension() {
+				restaurantExtension = policy.Extension(extension)
+			}
+
+			return processExtendKeys(ctx, apiClient, restaurant, restaurantExtension, policy, request.DryRun, restaurantResult)
+		},
+	})
+
+	log.Printf("🎉 GELATO! Продление ключей завершено: %d успешно, %d частично, %d ошибок",
+		result.Successful, result.Partial, result.Failed)
+
+	if !request.DryRun {
+		recordRestaurantStatus(models.OperationExtendKeys, job.ID(), result)
+	}
+
+	return result, interruptedError(ctx)
+}
+
+// runRefreshMenus выполняет обновление меню в рамках задачи
+func runRefreshMenus(ctx context.Context, job *jobs.Job, request OperationRequest) (*models.OperationResult, error) {
+	envConfig := config.LoadEnvConfig()
+
+	// Общий дедлайн на весь запуск
+	ctx, cancel := context.WithTimeout(ctx, envConfig.RunTimeout)
+	defer cancel()
+
+	// Загружаем рестораны
+	restaurants, err := loadRestaurants(ctx, request.Restaurants)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки ресторанов: %w", err)
+	}
+
+	result := runOperation(ctx, job, envConfig, restaurants, operation{
+		unit:     "меню",
+		dryRun:   request.DryRun,
+		selector: request.Restaurants,
+		process: func(ctx context.Context, apiClient *client.IikoClient, restaurant models.Restaurant, restaurantResult *models.RestaurantResult) error {
+			return processRefreshMenus(ctx, apiClient, restaurant, request.DryRun, restaurantResult)
+		},
+	})
+
+	log.Printf("🎉 GELATO! Обновление меню завершено: %d успешно, %d частично, %d ошибок",
+		result.Successful, result.Partial, result.Failed)
+
+	if !request.DryRun {
+		recordRestaurantStatus(models.OperationRefreshMenus, job.ID(), result)
+	}
+
+	return result, interruptedError(ctx)
+}
+
+// interruptedError возвращает ошибку, если запуск был отменен или превысил дедлайн
+func interruptedError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("операция прервана: %w", err)
+	}
+	return nil
+}
+
+// processFunc обрабатывает один ресторан через подготовленный клиент iiko.
+// Количество обновлений и результаты по логинам и меню записываются в restaurantResult;
+// ошибка означает, что ресторан не удалось обработать целиком
+type processFunc func(ctx context.Context, apiClient *client.IikoClient, restaurant models.Restaurant, restaurantResult *models.RestaurantResult) error
+
+// operation описывает запуск операции над ресторанами
+type operation struct {
+	unit     string                    // что обновляется, для сообщений: "ключей", "меню"
+	dryRun   bool                      // ничего не меняем в iiko, только показываем план
+	selector models.RestaurantSelector // какие рестораны выбраны, для результата
+	process  processFunc
+}
+
+// runOperation параллельно обрабатывает включенные рестораны и обновляет прогресс задачи.
+// Порядок Details совпадает с порядком ресторанов независимо от порядка завершения
+func runOperation(ctx context.Context, job *jobs.Job, envConfig *config.EnvConfig, restaurants []*models.Restaurant, op operation) *models.OperationResult {
+	startTime := time.Now()
+
+	// Отбираем включенные рестораны
+	var enabled []*models.Restaurant
+	for _, restaurant := range restaurants {
+		if !restaurant.Enabled {
+			log.Printf("⏭️  Ресторан %s отключен, пропускаем", restaurant.Name)
+			continue
+		}
+		enabled = append(enabled, restaurant)
+	}
+	job.SetTotal(len(enabled))
+
+	retryPolicy := newRetryPolicy(envConfig)
+
+	// Каждый воркер пишет только в свою ячейку, поэтому блокировка не нужна
+	details := make([]models.RestaurantResult, len(enabled))
+	pool := jobs.NewPool(envConfig.Concurrency, envConfig.DomainConcurrency)
+	pool.Run(len(enabled), func(i int) string {
+		return restaurantDomain(enabled[i])
+	}, func(i int) {
+		details[i] = processRestaurant(ctx, *enabled[i], retryPolicy, op)
+		job.Advance()
+	})
+
+	result := &models.OperationResult{
+		ProcessedRestaurants: len(restaurants),
+		DryRun:               op.dryRun,
+		Selector:             op.selector.String(),
+		Details:              details,
+	}
+	for _, detail := range details {
+		switch detail.Status {
+		case models.RestaurantStatusSuccess:
+			result.Successful++
+		case models.RestaurantStatusPartial:
+			result.Partial++
+		default:
+			result.Failed++
+		}
+	}
+
+	result.Duration = time.Since(startTime).String()
+
+	return result
+}
+
+// processRestaurant обрабатывает один ресторан и формирует его результат
+func processRestaurant(ctx context.Context, restaurant models.Restaurant, retryPolicy client.RetryPolicy, op operation) (restaurantResult models.RestaurantResult) {
+	restaurantResult = models.RestaurantResult{
+		ID:     restaurant.ID,
+		Name:   restaurant.Name,
+		Status: models.RestaurantStatusFailed,
+	}
+
+	// После отмены или дедлайна оставшиеся рестораны не трогаем
+	if err := ctx.Err(); err != nil {
+		restaurantResult.Error = fmt.Sprintf("операция прервана: %v", err)
+		restaurantResult.ErrorCode = client.ErrorCode(err)
+		return restaurantResult
+	}
+
+	apiClient := client.NewIikoClient(restaurant.BaseURL, restaurant.Login, restaurant.Password)
+	apiClient.SetRetryPolicy(retryPolicy)
+
+	// Счетчики попыток заполняем при любом исходе, включая панику
+	defer func() {
+		restaurantResult.Attempts = apiClient.Attempts()
+		restaurantResult.Retries = apiClient.Retries()
+	}()
+
+	// Паника в воркере уронила бы весь процесс
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Паника при обработке ресторана %s: %v", restaurant.Name, r)
+			restaurantResult.Success = false
+			restaurantResult.Status = models.RestaurantStatusFailed
+			restaurantResult.Error = fmt.Sprintf("паника: %v", r)
+			restaurantResult.ErrorCode = client.ErrorCodeUnknown
+		}
+	}()
+
+	if err := op.process(ctx, apiClient, restaurant, &restaurantResult); err != nil {
+		log.Printf("❌ Ошибка обработки ресторана %s: %v", restaurant.Name, err)
+		restaurantResult.Success = false
+		restaurantResult.Error = err.Error()
+		restaurantResult.ErrorCode = client.ErrorCode(err)
+		return restaurantResult
+	}
+
+	verb, messageVerb := "обновлено", "Обновлено"
+	if op.dryRun {
+		verb, messageVerb = "будет обновлено", "Будет обновлено"
+	}
+	restaurantResult.Message = fmt.Sprintf("%s %d %s", messageVerb, restaurantResult.Updated, op.unit)
+	if restaurantResult.Mismatch != "" {
+		restaurantResult.Message += fmt.Sprintf(" (%s)", restaurantResult.Mismatch)
+	}
+
+	// Ресторан частично неуспешен, если хотя бы один подходящий логин или меню не обработан
+	if failed := restaurantResult.FailedItems(); failed > 0 {
+		log.Printf("⚠️  Ресторан %s: %s %d %s, ошибок: %d", restaurant.Name, verb, restaurantResult.Updated, op.unit, failed)
+		restaurantResult.Success = false
+		restaurantResult.Status = models.RestaurantStatusPartial
+		restaurantResult.Message += fmt.Sprintf(", ошибок: %d", failed)
+		return restaurantResult
+	}
+
+	log.Printf("✅ Ресторан %s: %s %d %s", restaurant.Name, verb, restaurantResult.Updated, op.unit)
+	restaurantResult.Success = true
+	restaurantResult.Status = models.RestaurantStatusSuccess
+
+	return restaurantResult
+}
+
+// newRetryPolicy формирует политику повторных запросов к iiko из конфигурации
+func newRetryPolicy(envConfig *config.EnvConfig) client.RetryPolicy {
+	policy := client.DefaultRetryPolicy()
+	policy.MaxAttempts = envConfig.RetryMaxAttempts
+	policy.BaseDelay = envConfig.RetryBaseDelay
+	policy.MaxDelay = envConfig.RetryMaxDelay
+	policy.RetryMutations = envConfig.RetryMutations
+	return policy
+}
+
+// restaurantDomain возвращает домен iikoWeb ресторана для ограничения параллельности
+func restaurantDomain(restaurant *models.Restaurant) string {
+	parsed, err := url.Parse(restaurant.BaseURL)
+	if err != nil || parsed.Host == "" {
+		return restaurant.BaseURL
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// loadRestaurants загружает рестораны, подходящие под селектор, из базы данных
+func loadRestaurants(ctx context.Context, selector models.RestaurantSelector) ([]*models.Restaurant, error) {
+	restaurants, err := findRestaurants(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎯 Выбрано ресторанов: %d (%s)", len(restaurants), selector)
+	return restaurants, nil
+}
+
+// findRestaurants читает рестораны, подходящие под селектор, через общее подключение
+// и конвертирует их в формат для minion
+func findRestaurants(ctx context.Context, selector models.RestaurantSelector) ([]*models.Restaurant, error) {
+	restaurantService, err := restaurantStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoRestaurants, err := restaurantService.FindActiveIikoRestaurants(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var restaurants []*models.Restaurant
+	for _, mongoRestaurant := range mongoRestaurants {
+		if restaurant := mongoRestaurant.ToMinion(); restaurant != nil {
+			restaurants = append(restaurants, restaurant)
+		}
+	}
+	return restaurants, nil
+}
+
+// processExtendKeys обрабатывает продление ключей для одного ресторана
+// В режиме dry run логины читаются, но не сохраняются
+func processExtendKeys(ctx context.Context, apiClient *client.IikoClient, restaurant models.Restaurant, extension keys.Extension, policy keys.Policy, dryRun bool, restaurantResult *models.RestaurantResult) error {
+	if restaurant.IikoExternalMenuId == "" {
+		restaurantResult.Mismatch = models.MismatchMenuNotConfigured
+		return nil
+	}
+
+	// Авторизация (сессия переиспользуется, если уже есть)
+	if err := apiClient.Login(ctx); err != nil {
+		return fmt.Errorf("ошибка авторизации: %w", err)
+	}
+
+	// Получение API логинов
+	response, err := apiClient.GetApiLogins(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения API логинов: %w", err)
+	}
+
+	for _, apiLogin := range response.ApiLogins {
+		if !hasExternalMenu(apiLogin, restaurant.IikoExternalMenuId) {
+			continue
+		}
+
+		apiLoginResult := extendApiLogin(ctx, apiClient, apiLogin, extension, policy, dryRun)
+		if apiLoginResult.Action == models.ActionExtended || apiLoginResult.Action == models.ActionWouldExtend {
+			restaurantResult.Updated++
+		}
+		restaurantResult.ApiLogins = append(restaurantResult.ApiLogins, apiLoginResult)
+	}
+
+	if len(restaurantResult.ApiLogins) == 0 {
+		restaurantResult.Mismatch = models.MismatchMenuNotFound
+	}
+
+	return nil
+}
+
+// extendApiLogin продлевает один API логин и описывает, что с ним произошло
+func extendApiLogin(ctx context.Context, apiClient *client.IikoClient, apiLogin models.ApiLogin, extension keys.Extension, policy keys.Policy, dryRun bool) models.ApiLoginResult {
+	apiLoginResult := models.ApiLoginResult{
+		ID:            apiLogin.ID,
+		Name:          apiLogin.Name,
+		OldExpiration: apiLogin.ExpirationDate,
+	}
+
+	if !apiLogin.IsActive {
+		return skipApiLogin(apiLoginResult, models.SkipReasonInactive)
+	}
+
+	// Получаем детальную информацию
+	detailResponse, err := apiClient.GetApiLoginDetail(ctx, apiLogin.ID)
+	if err != nil {
+		return failApiLogin(apiLoginResult, fmt.Errorf("ошибка получения деталей логина: %w", err))
+	}
+
+	detail := detailResponse.ApiLoginInfo
+	if detail.ExpirationDate != nil {
+		apiLoginResult.OldExpiration = *detail.ExpirationDate
+	}
+
+	// Бессрочный режим: дату не трогаем, только выставляем isLongLived
+	if extension.LongLived {
+		if detail.IsLongLived {
+			return skipApiLogin(apiLoginResult, models.SkipReasonAlreadyLongLived)
+		}
+		detail.IsLongLived = true
+		apiLoginResult.LongLived = true
+		return saveApiLogin(ctx, apiClient, detail, apiLoginResult, dryRun)
+	}
+
+	if detail.ExpirationDate == nil {
+		return skipApiLogin(apiLoginResult, models.SkipReasonNoExpirationDate)
+	}
+
+	currentDate, err := time.Parse(keys.DateLayout, *detail.ExpirationDate)
+	if err != nil {
+		return failApiLogin(apiLoginResult, fmt.Errorf("ошибка парсинга даты %s: %v", *detail.ExpirationDate, err))
+	}
+
+	// Ключ, до истечения которого больше порога политики, продлевать еще рано
+	if !policy.Due(currentDate) {
+		return skipApiLogin(apiLoginResult, models.SkipReasonNotDue)
+	}
+
+	// Ключ никогда не укорачиваем: если новая дата не позже текущей, пропускаем
+	newDate := extension.Apply(currentDate)
+	if !newDate.After(currentDate) {
+		if newDate.Equal(extension.MaxDate) {
+			return skipApiLogin(apiLoginResult, models.SkipReasonAlreadyAtMax)
+		}
+		return skipApiLogin(apiLoginResult, models.SkipReasonAlreadyBeyondTarget)
+	}
+
+	newExpirationDate := newDate.Format(keys.DateLayout)
+	detail.ExpirationDate = &newExpirationDate
+	apiLoginResult.NewExpiration = newExpirationDate
+
+	return saveApiLogin(ctx, apiClient, detail, apiLoginResult, dryRun)
+}
+
+// saveApiLogin сохраняет измененный API логин, а в режиме dry run только отмечает, что он был бы продлен
+func saveApiLogin(ctx context.Context, apiClient *client.IikoClient, detail models.ApiLoginDetail, apiLoginResult models.ApiLoginResult, dryRun bool) models.ApiLoginResult {
+	if dryRun {
+		apiLoginResult.Action = models.ActionWouldExtend
+		return apiLoginResult
+	}
+
+	if err := apiClient.SaveApiLoginDetail(ctx, detail); err != nil {
+		return failApiLogin(apiLoginResult, fmt.Errorf("ошибка сохранения логина: %w", err))
+	}
+
+	apiLoginResult.Action = models.ActionExtended
+
+	return apiLoginResult
+}
+
+// skipApiLogin помечает API логин пропущенным
+func skipApiLogin(apiLoginResult models.ApiLoginResult, reason string) models.ApiLoginResult {
+	apiLoginResult.Action = models.ActionSkipped
+	apiLoginResult.SkipReason = reason
+	return apiLoginResult
+}
+
+// failApiLogin помечает API логин необработанным из-за ошибки
+func failApiLogin(apiLoginResult models.ApiLoginResult, err error) models.ApiLoginResult {
+	apiLoginResult.Action = models.ActionFailed
+	apiLoginResult.Error = err.Error()
+	apiLoginResult.ErrorCode = client.ErrorCode(err)
+	return apiLoginResult
+}
+
+// hasExternalMenu проверяет, привязано ли к API логину внешнее меню ресторана
+func hasExternalMenu(apiLogin models.ApiLogin, externalMenuID string) bool {
+	for _, externalMenu := range apiLogin.ExternalMenus {
+		if externalMenu.ID == externalMenuID {
+			return true
+		}
+	}
+	return false
+}
+
+// processRefreshMenus обрабатывает обновление меню для одного ресторана
+// В режиме dry run меню только ищутся, но не обновляются
+func processRefreshMenus(ctx context.Context, apiClient *client.IikoClient, restaurant models.Restaurant, dryRun bool, restaurantResult *models.RestaurantResult) error {
+	if restaurant.IikoExternalMenuId == "" {
+		restaurantResult.Mismatch = models.MismatchMenuNotConfigured
+		return nil
+	}
+
+	// Авторизация (сессия переиспользуется, если уже есть)
+	if err := apiClient.Login(ctx); err != nil {
+		return fmt.Errorf("ошибка авторизации: %w", err)
+	}
+
+	// Получение списка внешних меню
+	menus, err := apiClient.GetExternalMenus(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения меню: %w", err)
+	}
+
+	for _, menu := range menus.Data {
+		if strconv.Itoa(menu.ID) != restaurant.IikoExternalMenuId {
+			continue
+		}
+
+		menuResult := models.MenuResult{
+			ID:     menu.ID,
+			Name:   menu.Name,
+			Action: models.ActionRefreshed,
+		}
+
+		if dryRun {
+			menuResult.Action = models.ActionWouldRefresh
+			restaurantResult.Updated++
+		} else if err := apiClient.RefreshExternalMenu(ctx, menu.ID); err != nil {
+			menuResult.Action = models.ActionFailed
+			menuResult.Error = err.Error()
+			menuResult.ErrorCode = client.ErrorCode(err)
+		} else {
+			restaurantResult.Updated++
+		}
+
+		restaurantResult.Menus = append(restaurantResult.Menus, menuResult)
+	}
+
+	if len(restaurantResult.Menus) == 0 {
+		restaurantResult.Mismatch = models.MismatchMenuNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"minion/internal/auth"
+	"minion/internal/database"
+	"minion/internal/keys"
+	"minion/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OperationRequest содержит параметры запуска операции из JSON тела или query параметров
+type OperationRequest struct {
+	DryRun bool `json:"dry_run"` // только показать, что будет сделано, ничего не меняя в iiko
+
+	// Какие рестораны обрабатывать; пустой селектор - все активные iiko рестораны
+	Restaurants models.RestaurantSelector `json:"restaurants"`
+
+	// Параметры продления ключей (только extend-keys); если не заданы, берутся из конфигурации
+	Years      *int   `json:"years"`
+	Months     *int   `json:"months"`
+	Days       *int   `json:"days"`
+	TargetDate string `json:"target_date"` // ДД.ММ.ГГГГ или ГГГГ-ММ-ДД
+	LongLived  bool   `json:"long_lived"`
+}
+
+// parseOperationRequest разбирает параметры запуска операции.
+// Ошибка означает некорректный запрос и возвращается клиенту как 400
+func parseOperationRequest(c *fiber.Ctx) (OperationRequest, error) {
+	var request OperationRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return request, fmt.Errorf("некорректное тело запроса: %v", err)
+		}
+	}
+
+	// Query параметр дополняет тело: ?dry_run=true
+	if value := c.Query("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return request, fmt.Errorf("некорректное значение dry_run: %s", value)
+		}
+		request.DryRun = request.DryRun || dryRun
+	}
+
+	if err := request.Restaurants.Validate(); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// parseSelectorQuery разбирает селектор ресторанов из query параметров GET запросов:
+// ?names=a,b&cities=Алматы&ids=...&domains=...&pos_types=...
+func parseSelectorQuery(c *fiber.Ctx) (models.RestaurantSelector, error) {
+	selector := models.RestaurantSelector{
+		IDs:      queryList(c, "ids"),
+		Names:    queryList(c, "names"),
+		Cities:   queryList(c, "cities"),
+		PosTypes: queryList(c, "pos_types"),
+		Domains:  queryList(c, "domains"),
+	}
+	return selector, selector.Validate()
+}
+
+// queryList разбирает query параметр со значениями через запятую
+func queryList(c *fiber.Ctx, key string) []string {
+	value := c.Query(key)
+	if value == "" {
+		return nil
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// hasKeyExtension проверяет, заданы ли в запросе параметры продления ключей
+func (r OperationRequest) hasKeyExtension() bool {
+	return r.Years != nil || r.Months != nil || r.Days != nil || r.TargetDate != "" || r.LongLived
+}
+
+// keyExtension формирует продление ключей из запроса, а если параметров нет - возвращает defaults
+func (r OperationRequest) keyExtension(defaults keys.Extension) (keys.Extension, error) {
+	if !r.hasKeyExtension() {
+		return defaults, nil
+	}
+
+	extension := keys.Extension{
+		LongLived: r.LongLived,
+		MaxDate:   defaults.MaxDate,
+	}
+	if r.Years != nil {
+		extension.Years = *r.Years
+	}
+	if r.Months != nil {
+		extension.Months = *r.Months
+	}
+	if r.Days != nil {
+		extension.Days = *r.Days
+	}
+	if r.TargetDate != "" {
+		targetDate, err := keys.ParseDate(r.TargetDate)
+		if err != nil {
+			return extension, err
+		}
+		extension.TargetDate = targetDate
+	}
+
+	// Явно переданные нули без других параметров - ошибка, а не продление по умолчанию
+	if err := extension.Validate(); err != nil {
+		return extension, err
+	}
+
+	return extension, nil
+}
+
+// invalidRequestError - некорректные параметры запуска, возвращаются клиенту как 400
+type invalidRequestError struct {
+	err error
+}
+
+func (e *invalidRequestError) Error() string {
+	return e.err.Error()
+}
+
+// submitError возвращает ответ на ошибку запуска операции: 400 для некорректных параметров,
+// 409 если операция уже выполняется, 503 если не удалось захватить блокировку
+func submitError(c *fiber.Ctx, err error) error {
+	var (
+		invalidErr *invalidRequestError
+		heldErr    *database.LeaseHeldError
+	)
+
+	switch {
+	case errors.As(err, &invalidErr):
+		return badRequest(c, err)
+	case errors.As(err, &heldErr):
+		return c.Status(fiber.StatusConflict).JSON(APIResponse{
+			Success: false,
+			Message: "Операция уже выполняется",
+			Error:   err.Error(),
+			Data: fiber.Map{
+				"operation":  heldErr.Name,
+				"instance":   heldErr.Instance,
+				"job_id":     heldErr.JobID,
+				"expires_at": heldErr.ExpiresAt,
+			},
+		})
+	}
+
+	return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+		Success: false,
+		Message: "Не удалось захватить блокировку операции",
+		Error:   err.Error(),
+	})
+}
+
+// manualTrigger описывает запуск операции запросом к API
+func manualTrigger(c *fiber.Ctx) models.Trigger {
+	trigger := models.Trigger{Type: models.TriggerManual, CallerIP: c.IP()}
+	if identity, ok := c.Locals(identityLocal).(auth.Identity); ok {
+		trigger.Identity = identity.String()
+	}
+	return trigger
+}
+
+// badRequest возвращает ответ 400 в стандартном формате
+func badRequest(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(APIResponse{
+		Success: false,
+		Message: "Некорректные параметры запроса",
+		Error:   err.Error(),
+	})
+}
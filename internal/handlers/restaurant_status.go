@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"minion/internal/client"
+	"minion/internal/keys"
+	"minion/internal/models"
+)
+
+// recordRestaurantStatus записывает итоги запуска в поля minion.* документов ресторанов,
+// чтобы другие сервисы и админка видели состояние ключей и меню прямо в ресторане.
+// Ошибка записи не влияет на результат операции
+func recordRestaurantStatus(operation, runID string, result *models.OperationResult) {
+	now := time.Now()
+
+	var updates []models.MinionStatusUpdate
+	for _, detail := range result.Details {
+		if update, ok := restaurantStatusUpdate(operation, runID, detail, now); ok {
+			updates = append(updates, update)
+		}
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	// Контекст задачи к этому моменту может быть уже отменен
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	restaurantService, err := restaurantStore(ctx)
+	if err != nil {
+		log.Printf("⚠️ Не удалось записать статус в рестораны: %v", err)
+		return
+	}
+
+	if err := restaurantService.UpdateMinionStatuses(ctx, updates); err != nil {
+		log.Printf("⚠️ %v", err)
+		return
+	}
+
+	log.Printf("📝 Статус запуска записан в %d ресторанов", len(updates))
+}
+
+// restaurantStatusUpdate формирует обновление документа ресторана по его результату.
+// Рестораны, до которых запуск не дошел из-за отмены, не обновляются
+func restaurantStatusUpdate(operation, runID string, detail models.RestaurantResult, now time.Time) (models.MinionStatusUpdate, bool) {
+	if detail.ID == "" || detail.ErrorCode == client.ErrorCodeCancelled {
+		return models.MinionStatusUpdate{}, false
+	}
+
+	update := models.MinionStatusUpdate{
+		RestaurantID: detail.ID,
+		RunID:        runID,
+		LastError:    restaurantError(detail),
+		At:           now,
+	}
+
+	switch operation {
+	case models.OperationExtendKeys:
+		for _, apiLogin := range detail.ApiLogins {
+			if apiLogin.Action == models.ActionExtended {
+				update.LastKeyExtensionAt = &now
+				break
+			}
+		}
+		update.KeyExpiresAt = soonestExpiration(detail.ApiLogins)
+	case models.OperationRefreshMenus:
+		for _, menu := range detail.Menus {
+			if menu.Action == models.ActionRefreshed {
+				update.LastMenuRefreshAt = &now
+				break
+			}
+		}
+	}
+
+	return update, true
+}
+
+// restaurantError возвращает ошибку ресторана или первого необработанного логина или меню
+func restaurantError(detail models.RestaurantResult) string {
+	if detail.Error != "" {
+		return detail.Error
+	}
+	for _, apiLogin := range detail.ApiLogins {
+		if apiLogin.Action == models.ActionFailed {
+			return fmt.Sprintf("API логин %s: %s", apiLogin.Name, apiLogin.Error)
+		}
+	}
+	for _, menu := range detail.Menus {
+		if menu.Action == models.ActionFailed {
+			return fmt.Sprintf("меню %s: %s", menu.Name, menu.Error)
+		}
+	}
+	return ""
+}
+
+// soonestExpiration возвращает ближайшую дату истечения активных API логинов с датой; бессрочные не учитываются
+func soonestExpiration(apiLogins []models.ApiLoginResult) *time.Time {
+	var soonest *time.Time
+	for _, apiLogin := range apiLogins {
+		if apiLogin.LongLived || apiLogin.SkipReason == models.SkipReasonInactive ||
+			apiLogin.SkipReason == models.SkipReasonAlreadyLongLived {
+			continue
+		}
+
+		expiration := apiLogin.OldExpiration
+		if apiLogin.Action == models.ActionExtended {
+			expiration = apiLogin.NewExpiration
+		}
+
+		date, err := time.Parse(keys.DateLayout, expiration)
+		if err != nil {
+			continue
+		}
+		if soonest == nil || date.Before(*soonest) {
+			soonest = &date
+		}
+	}
+	return soonest
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"minion/internal/config"
+	"minion/internal/database"
+	"minion/internal/jobs"
+	"minion/internal/models"
+	"minion/internal/schedule"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// scheduler запускает операции по расписаниям из конфигурации; nil до StartScheduler
+var scheduler *schedule.Scheduler
+
+// StartScheduler создает расписания из конфигурации и запускает их в фоне.
+// Плановые запуски проходят через тот же код, что и запросы к API
+func StartScheduler() error {
+	envConfig := config.LoadEnvConfig()
+
+	location, err := time.LoadLocation(envConfig.ScheduleTimezone)
+	if err != nil {
+		return fmt.Errorf("неизвестный часовой пояс %s: %v", envConfig.ScheduleTimezone, err)
+	}
+
+	s := schedule.NewScheduler(location)
+	if envConfig.ScheduleExtendKeys != "" {
+		err := s.Add(models.OperationExtendKeys, envConfig.ScheduleExtendKeys, func() (*jobs.Job, error) {
+			job, _, err := submitExtendKeys(OperationRequest{}, models.Trigger{Type: models.TriggerSchedule})
+			return job, scheduleError(err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if envConfig.ScheduleRefreshMenus != "" {
+		err := s.Add(models.OperationRefreshMenus, envConfig.ScheduleRefreshMenus, func() (*jobs.Job, error) {
+			job, err := submitRefreshMenus(OperationRequest{}, models.Trigger{Type: models.TriggerSchedule})
+			return job, scheduleError(err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Паузы хранятся в MongoDB, чтобы пауза через любую реплику действовала на все
+	s.SetPausedFunc(func(ctx context.Context, name string) (bool, error) {
+		schedules, err := scheduleStore(ctx)
+		if err != nil {
+			return false, err
+		}
+		return schedules.Paused(ctx, name)
+	})
+
+	s.Start()
+	scheduler = s
+
+	for _, info := range s.List() {
+		log.Printf("⏰ Расписание %s: %s (%s)", info.Name, info.Expression, info.Timezone)
+	}
+
+	return nil
+}
+
+// scheduleError помечает срабатывание пропущенным, если операция уже выполняется на этой или другой реплике
+func scheduleError(err error) error {
+	var heldErr *database.LeaseHeldError
+	if errors.As(err, &heldErr) {
+		return fmt.Errorf("%w: %v", schedule.ErrSkipped, err)
+	}
+	return err
+}
+
+// GetSchedules возвращает расписания с временем следующего запуска и итогом последнего
+func GetSchedules(c *fiber.Ctx) error {
+	schedules := []schedule.Info{}
+	if scheduler != nil {
+		scheduler.Refresh(c.UserContext())
+		schedules = scheduler.List()
+	}
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: "⏰ Расписания",
+		Data:    schedules,
+	})
+}
+
+// PauseSchedule приостанавливает расписание
+func PauseSchedule(c *fiber.Ctx) error {
+	return setSchedulePaused(c, true)
+}
+
+// ResumeSchedule возобновляет расписание
+func ResumeSchedule(c *fiber.Ctx) error {
+	return setSchedulePaused(c, false)
+}
+
+// setSchedulePaused ставит расписание на паузу или снимает с нее на всех репликах
+func setSchedulePaused(c *fiber.Ctx, paused bool) error {
+	name := c.Params("name")
+
+	if scheduler == nil {
+		return scheduleNotFound(c, name)
+	}
+	if _, ok := scheduler.Get(name); !ok {
+		return scheduleNotFound(c, name)
+	}
+
+	schedules, err := scheduleStore(c.UserContext())
+	if err == nil {
+		err = schedules.SetPaused(c.UserContext(), name, paused, RequestIdentity(c))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(APIResponse{
+			Success: false,
+			Message: "Не удалось изменить состояние расписания",
+			Error:   err.Error(),
+		})
+	}
+
+	// Эта реплика видит изменение сразу, остальные - при следующей проверке
+	if paused {
+		scheduler.Pause(name)
+	} else {
+		scheduler.Resume(name)
+	}
+
+	message := "▶️ Расписание возобновлено"
+	if paused {
+		message = "⏸️ Расписание приостановлено"
+	}
+	log.Printf("%s: %s (%s)", message, name, c.IP())
+
+	info, _ := scheduler.Get(name)
+
+	return c.JSON(APIResponse{
+		Success: true,
+		Message: message,
+		Data:    info,
+	})
+}
+
+// scheduleNotFound возвращает ответ 404 для расписания, которое не настроено
+func scheduleNotFound(c *fiber.Ctx, name string) error {
+	return c.Status(fiber.StatusNotFound).JSON(APIResponse{
+		Success: false,
+		Message: "Расписание не найдено",
+		Error:   fmt.Sprintf("расписание %s не настроено", name),
+	})
+}
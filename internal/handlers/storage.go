@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"minion/internal/config"
+	"minion/internal/database"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// storage - общее подключение к MongoDB и сервисы поверх него. Создаются один раз,
+// вместе с индексами; если база недоступна, создание повторяется при следующем обращении
+var storage struct {
+	sync.Mutex
+	db          *mongo.Database
+	restaurants *database.RestaurantService
+	leases      *database.LeaseService
+	runs        *database.RunService
+	alerts      *database.AlertService
+	schedules   *database.ScheduleService
+	audit       *database.AuditService
+	keyReports  *database.KeyReportService
+}
+
+// StartStorage подключается к MongoDB при старте сервера. Недоступная база не мешает запуску:
+// операции, которым она нужна, вернут ошибку и попробуют подключиться снова
+func StartStorage() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := leaseStore(ctx); err != nil {
+		log.Printf("⚠️ MongoDB недоступна при старте: %v", err)
+		return
+	}
+	if _, err := runStore(ctx); err != nil {
+		log.Printf("⚠️ История запусков недоступна при старте: %v", err)
+	}
+	if _, err := auditStore(ctx); err != nil {
+		log.Printf("⚠️ Журнал аудита недоступен при старте: %v", err)
+	}
+
+	log.Println("🗄️ Подключение к MongoDB установлено")
+}
+
+// storageDatabaseLocked возвращает общее подключение, подключаясь при первом обращении
+func storageDatabaseLocked(ctx context.Context) (*mongo.Database, error) {
+	if storage.db != nil {
+		return storage.db, nil
+	}
+
+	db, err := config.ConnectDatabase(ctx, config.LoadEnvConfig())
+	if err != nil {
+		return nil, err
+	}
+	storage.db = db
+	return db, nil
+}
+
+// restaurantStore возвращает общий сервис коллекции ресторанов
+func restaurantStore(ctx context.Context) (*database.RestaurantService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.restaurants != nil {
+		return storage.restaurants, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storage.restaurants = database.NewRestaurantService(db)
+	return storage.restaurants, nil
+}
+
+// leaseStore возвращает общий сервис блокировок операций
+func leaseStore(ctx context.Context) (*database.LeaseService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.leases != nil {
+		return storage.leases, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if storage.leases, err = database.NewLeaseService(ctx, db); err != nil {
+		return nil, err
+	}
+	return storage.leases, nil
+}
+
+// runStore возвращает общий сервис истории запусков
+func runStore(ctx context.Context) (*database.RunService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.runs != nil {
+		return storage.runs, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if storage.runs, err = database.NewRunService(ctx, db); err != nil {
+		return nil, err
+	}
+	return storage.runs, nil
+}
+
+// alertStore возвращает общий сервис предупреждений об истекающих ключах
+func alertStore(ctx context.Context) (*database.AlertService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.alerts != nil {
+		return storage.alerts, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storage.alerts = database.NewAlertService(db)
+	return storage.alerts, nil
+}
+
+// scheduleStore возвращает общий сервис состояния расписаний
+func scheduleStore(ctx context.Context) (*database.ScheduleService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.schedules != nil {
+		return storage.schedules, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storage.schedules = database.NewScheduleService(db)
+	return storage.schedules, nil
+}
+
+// auditStore возвращает общий сервис журнала аудита
+func auditStore(ctx context.Context) (*database.AuditService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.audit != nil {
+		return storage.audit, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if storage.audit, err = database.NewAuditService(ctx, db); err != nil {
+		return nil, err
+	}
+	return storage.audit, nil
+}
+
+// keyReportStore возвращает общий сервис последнего отчета по ключам
+func keyReportStore(ctx context.Context) (*database.KeyReportService, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.keyReports != nil {
+		return storage.keyReports, nil
+	}
+
+	db, err := storageDatabaseLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storage.keyReports = database.NewKeyReportService(db)
+	return storage.keyReports, nil
+}
+
+// closeStorage закрывает общее подключение при остановке сервера
+func closeStorage() {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.db == nil {
+		return
+	}
+	if err := database.Disconnect(storage.db); err != nil {
+		log.Printf("⚠️ Ошибка закрытия подключения к MongoDB: %v", err)
+	}
+	storage.db = nil
+	storage.restaurants = nil
+	storage.leases = nil
+	storage.runs = nil
+	storage.alerts = nil
+	storage.schedules = nil
+	storage.audit = nil
+	storage.keyReports = nil
+}
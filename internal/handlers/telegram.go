@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"minion/internal/config"
+	"minion/internal/database"
+	"minion/internal/jobs"
+	"minion/internal/models"
+	"minion/internal/notify"
+	"minion/internal/telegram"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// telegramBot - бот операторов; nil, если TELEGRAM_BOT_TOKEN не задан
+var telegramBot *telegram.Bot
+
+// telegramHelp - ответ на /start и /help
+const telegramHelp = `🍌 Minion
+/refresh <ресторан> - обновить меню ресторана
+/keys <ресторан> - сроки действия API ключей ресторана
+/status - выполняющиеся задачи, расписания и предупреждения`
+
+// StartTelegram запускает бота и подписывает его на оповещения; вызывается после StartNotifier
+func StartTelegram() error {
+	envConfig := config.LoadEnvConfig()
+	if envConfig.TelegramBotToken == "" {
+		return nil
+	}
+
+	chats, err := telegram.ParseChatIDs(envConfig.TelegramChatIDs)
+	if err != nil {
+		return fmt.Errorf("TELEGRAM_CHAT_IDS: %w", err)
+	}
+	events, err := notify.ParseEvents(envConfig.TelegramEvents)
+	if err != nil {
+		return fmt.Errorf("TELEGRAM_EVENTS: %w", err)
+	}
+
+	telegramBot = telegram.NewBot(telegram.NewClient(envConfig.TelegramAPIURL, envConfig.TelegramBotToken), chats)
+	telegramBot.Handle("start", telegramHelpCommand)
+	telegramBot.Handle("help", telegramHelpCommand)
+	telegramBot.Handle("refresh", telegramRefresh)
+	telegramBot.Handle("keys", telegramKeys)
+	telegramBot.Handle("status", telegramStatus)
+
+	notifier.Add(telegramBot, events)
+	telegramBot.Start()
+
+	log.Printf("🤖 Telegram бот запущен: чаты %v, оповещения %v", chats, events)
+	return nil
+}
+
+// telegramHelpCommand отвечает списком команд
+func telegramHelpCommand(ctx context.Context, command telegram.Command) string {
+	return telegramHelp
+}
+
+// telegramRefresh запускает обновление меню одного ресторана
+func telegramRefresh(ctx context.Context, command telegram.Command) string {
+	trigger := models.Trigger{Type: models.TriggerTelegram, Identity: fmt.Sprintf("telegram:%s@%d", command.User, command.ChatID)}
+
+	selector, reply := telegramSelector(ctx, command)
+	if reply != "" {
+		auditTelegram(command, trigger, nil, "", fiber.StatusBadRequest, reply)
+		return reply
+	}
+
+	job, err := submitRefreshMenus(OperationRequest{Restaurants: selector}, trigger)
+	if err != nil {
+		var heldErr *database.LeaseHeldError
+		if errors.As(err, &heldErr) {
+			auditTelegram(command, trigger, &selector, "", fiber.StatusConflict, err.Error())
+			return "⏳ " + err.Error()
+		}
+		auditTelegram(command, trigger, &selector, "", fiber.StatusServiceUnavailable, err.Error())
+		return "❌ Не удалось запустить обновление меню: " + err.Error()
+	}
+
+	auditTelegram(command, trigger, &selector, job.ID(), fiber.StatusAccepted, "")
+	return fmt.Sprintf("🍽️ Обновление меню %s запущено, задача %s", command.Args, job.ID())
+}
+
+// auditTelegram записывает изменяющую команду Telegram в журнал аудита.
+// status - HTTP статус, которым ответил бы на такой же вызов API
+func auditTelegram(command telegram.Command, trigger models.Trigger, selector *models.RestaurantSelector, jobID string, status int, errorText string) {
+	entry := &models.AuditEntry{
+		Time:     time.Now(),
+		Source:   models.AuditSourceTelegram,
+		Identity: trigger.Identity,
+		Method:   "TELEGRAM",
+		Endpoint: "/" + command.Name,
+		Params:   models.AuditParams{Body: command.Args},
+		Selector: selector,
+		JobID:    jobID,
+		Status:   status,
+		Outcome:  auditOutcome(status),
+		Error:    errorText,
+	}
+	recordAudit(entry)
+}
+
+// telegramKeys отвечает сроками действия API ключей ресторана
+func telegramKeys(ctx context.Context, command telegram.Command) string {
+	selector, reply := telegramSelector(ctx, command)
+	if reply != "" {
+		return reply
+	}
+
+	report, err := collectKeyReport(ctx, config.LoadEnvConfig(), selector)
+	if err != nil {
+		return "❌ Не удалось получить ключи: " + err.Error()
+	}
+
+	var lines []string
+	for _, entry := range report.Keys {
+		line := fmt.Sprintf("• %s / %s: ", entry.Restaurant, entry.ApiLogin)
+		switch {
+		case entry.IsLongLived:
+			line += "бессрочный"
+		case entry.DaysRemaining != nil:
+			line += fmt.Sprintf("до %s (%d дн.)", entry.ExpirationDate, *entry.DaysRemaining)
+		default:
+			line += "без даты"
+		}
+		if !entry.IsActive {
+			line += ", неактивен"
+		}
+		if entry.RestaurantMenu {
+			line += ", меню ресторана"
+		}
+		lines = append(lines, line)
+	}
+	for _, reportError := range report.Errors {
+		lines = append(lines, fmt.Sprintf("❌ %s: %s", reportError.Restaurant, reportError.Error))
+	}
+	if len(lines) == 0 {
+		return "🔑 API логинов не найдено"
+	}
+
+	return "🔑 API ключи:\n" + strings.Join(lines, "\n")
+}
+
+// telegramStatus отвечает выполняющимися задачами, расписаниями и активными предупреждениями
+func telegramStatus(ctx context.Context, command telegram.Command) string {
+	lines := []string{"📋 Задачи:"}
+	running := 0
+	for _, info := range jobManager.List() {
+		if info.Status != jobs.StatusRunning && info.Status != jobs.StatusPending {
+			continue
+		}
+		running++
+		lines = append(lines, fmt.Sprintf("• %s %s: %s, %d/%d", info.ID, info.Operation, info.Status,
+			info.Progress.Done, info.Progress.Total))
+	}
+	if running == 0 {
+		lines = append(lines, "• нет выполняющихся задач")
+	}
+
+	if scheduler != nil {
+		lines = append(lines, "⏰ Расписания:")
+		for _, info := range scheduler.List() {
+			line := fmt.Sprintf("• %s (%s)", info.Name, info.Expression)
+			switch {
+			case info.Paused:
+				line += ": на паузе"
+			case info.NextRun != nil:
+				line += ": следующий запуск " + info.NextRun.Format("02.01.2006 15:04")
+			}
+			if info.LastRun != nil {
+				line += ", последний: " + info.LastRun.Status
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if keyMonitor != nil {
+		lines = append(lines, telegramAlerts(ctx))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// telegramAlerts описывает активные предупреждения об истекающих ключах
+func telegramAlerts(ctx context.Context) string {
+	alertService, err := alertStore(ctx)
+	if err != nil {
+		return "🚨 Предупреждения недоступны: " + err.Error()
+	}
+
+	alerts, err := alertService.List(ctx)
+	if err != nil {
+		return "🚨 Предупреждения недоступны: " + err.Error()
+	}
+
+	return fmt.Sprintf("🚨 Активных предупреждений об истекающих ключах: %d", len(alerts))
+}
+
+// telegramSelector выбирает ресторан по id или точному названию из аргумента команды.
+// reply - ответ пользователю, если ресторан не указан или не найден
+func telegramSelector(ctx context.Context, command telegram.Command) (selector models.RestaurantSelector, reply string) {
+	if command.Args == "" {
+		return selector, fmt.Sprintf("Укажите ресторан: /%s <название или id>", command.Name)
+	}
+
+	if _, err := primitive.ObjectIDFromHex(command.Args); err == nil {
+		selector.IDs = []string{command.Args}
+	} else {
+		selector.Names = []string{command.Args}
+	}
+
+	restaurants, err := loadRestaurants(ctx, selector)
+	if err != nil {
+		return selector, "❌ Не удалось загрузить рестораны: " + err.Error()
+	}
+	if len(restaurants) == 0 {
+		return selector, fmt.Sprintf("🤷 Ресторан %s не найден", command.Args)
+	}
+
+	return selector, ""
+}
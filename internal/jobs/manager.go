@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"minion/internal/models"
+)
+
+// Статусы задачи
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// maxFinishedJobs - сколько завершенных задач храним в памяти
+const maxFinishedJobs = 100
+
+// RunFunc выполняет операцию в рамках задачи; ctx отменяется при отмене задачи или остановке сервера
+type RunFunc func(ctx context.Context, job *Job) (*models.OperationResult, error)
+
+// Progress содержит прогресс выполнения задачи
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// JobInfo - снимок состояния задачи для API
+type JobInfo struct {
+	ID         string                  `json:"id"`
+	Operation  string                  `json:"operation"`
+	Status     string                  `json:"status"`
+	Progress   Progress                `json:"progress"`
+	Result     *models.OperationResult `json:"result,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+}
+
+// Job - асинхронная задача, выполняющая операцию над ресторанами
+type Job struct {
+	mu     sync.RWMutex
+	info   JobInfo
+	cancel context.CancelFunc
+}
+
+// ID возвращает идентификатор задачи
+func (j *Job) ID() string {
+	return j.info.ID
+}
+
+// SetTotal задает общее количество ресторанов для обработки
+func (j *Job) SetTotal(total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.info.Progress.Total = total
+}
+
+// Advance отмечает обработку еще одного ресторана
+func (j *Job) Advance() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.info.Progress.Done++
+}
+
+// Info возвращает копию текущего состояния задачи
+func (j *Job) Info() JobInfo {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.info
+}
+
+// finished проверяет, завершена ли задача
+func (j *Job) finished() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.info.Status == StatusCompleted || j.info.Status == StatusFailed || j.info.Status == StatusCancelled
+}
+
+// Manager управляет асинхронными задачами
+type Manager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+
+	// Выполняющиеся задачи, чтобы при остановке дождаться их завершения
+	running sync.WaitGroup
+
+	// Базовый контекст всех задач, отменяется при остановке сервера
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager создает новый менеджер задач
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		jobs:   make(map[string]*Job),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Submit создает задачу и запускает ее в фоне
+func (m *Manager) Submit(operation string, run RunFunc) *Job {
+	ctx, cancel := context.WithCancel(m.ctx)
+	job := &Job{
+		info: JobInfo{
+			ID:        newJobID(),
+			Operation: operation,
+			Status:    StatusPending,
+			CreatedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.info.ID] = job
+	m.evictLocked()
+	m.mu.Unlock()
+
+	m.running.Add(1)
+	go m.execute(ctx, job, run)
+
+	return job
+}
+
+// Get возвращает задачу по идентификатору
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// Cancel отменяет незавершенную задачу; false, если задача не найдена или уже завершена
+func (m *Manager) Cancel(id string) bool {
+	job, ok := m.Get(id)
+	if !ok || job.finished() {
+		return false
+	}
+
+	log.Printf("🛑 Отмена задачи %s (%s)", job.info.ID, job.info.Operation)
+	job.cancel()
+	return true
+}
+
+// Shutdown отменяет все выполняющиеся задачи и ждет их завершения не дольше timeout,
+// чтобы задачи успели освободить блокировки и записать итог. false - не все задачи успели завершиться
+func (m *Manager) Shutdown(timeout time.Duration) bool {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// List возвращает все задачи, начиная с самых новых
+func (m *Manager) List() []JobInfo {
+	m.mu.RLock()
+	infos := make([]JobInfo, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		infos = append(infos, job.Info())
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, k int) bool {
+		return infos[i].CreatedAt.After(infos[k].CreatedAt)
+	})
+
+	return infos
+}
+
+// execute выполняет задачу и сохраняет результат
+func (m *Manager) execute(ctx context.Context, job *Job, run RunFunc) {
+	defer m.running.Done()
+	defer job.cancel()
+
+	startedAt := time.Now()
+	job.mu.Lock()
+	job.info.Status = StatusRunning
+	job.info.StartedAt = &startedAt
+	job.mu.Unlock()
+
+	result, err := safeRun(ctx, job, run)
+
+	finishedAt := time.Now()
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	job.info.FinishedAt = &finishedAt
+	job.info.Result = result
+	job.info.Status = FinalStatus(err)
+
+	switch job.info.Status {
+	case StatusCancelled:
+		log.Printf("🛑 Задача %s (%s) отменена", job.info.ID, job.info.Operation)
+		job.info.Error = err.Error()
+	case StatusFailed:
+		log.Printf("❌ Задача %s (%s) завершилась с ошибкой: %v", job.info.ID, job.info.Operation, err)
+		job.info.Error = err.Error()
+	}
+}
+
+// FinalStatus возвращает конечный статус задачи по ошибке, с которой завершилась операция
+func FinalStatus(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusCancelled
+	case err != nil:
+		return StatusFailed
+	}
+	return StatusCompleted
+}
+
+// safeRun выполняет операцию, перехватывая панику
+func safeRun(ctx context.Context, job *Job, run RunFunc) (result *models.OperationResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника при выполнении задачи: %v", r)
+		}
+	}()
+
+	return run(ctx, job)
+}
+
+// evictLocked удаляет самые старые завершенные задачи сверх лимита
+func (m *Manager) evictLocked() {
+	var finished []*Job
+	for _, job := range m.jobs {
+		if job.finished() {
+			finished = append(finished, job)
+		}
+	}
+
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].info.CreatedAt.Before(finished[k].info.CreatedAt)
+	})
+
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, job.info.ID)
+	}
+}
+
+// newJobID генерирует случайный идентификатор задачи
+func newJobID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
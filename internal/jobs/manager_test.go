@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"minion/internal/models"
+)
+
+func TestManagerShutdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        RunFunc
+		wantDone   bool
+		wantStatus string
+	}{
+		{
+			name: "задача завершается по отмене",
+			run: func(ctx context.Context, job *Job) (*models.OperationResult, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantDone:   true,
+			wantStatus: StatusCancelled,
+		},
+		{
+			name: "задача игнорирует отмену дольше таймаута",
+			run: func(ctx context.Context, job *Job) (*models.OperationResult, error) {
+				time.Sleep(200 * time.Millisecond)
+				return &models.OperationResult{}, nil
+			},
+			wantDone:   false,
+			wantStatus: StatusRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			started := make(chan struct{})
+			job := manager.Submit("test", func(ctx context.Context, job *Job) (*models.OperationResult, error) {
+				close(started)
+				return tt.run(ctx, job)
+			})
+			<-started
+
+			if done := manager.Shutdown(50 * time.Millisecond); done != tt.wantDone {
+				t.Errorf("Shutdown() = %t, ожидалось %t", done, tt.wantDone)
+			}
+			if status := job.Info().Status; status != tt.wantStatus {
+				t.Errorf("статус задачи %s, ожидался %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestManagerRecoversPanic(t *testing.T) {
+	manager := NewManager()
+	job := manager.Submit("test", func(ctx context.Context, job *Job) (*models.OperationResult, error) {
+		panic("сбой")
+	})
+
+	if !manager.Shutdown(time.Second) {
+		t.Fatal("задача не завершилась")
+	}
+	if info := job.Info(); info.Status != StatusFailed || info.Error == "" {
+		t.Errorf("статус %s, ошибка %q, ожидалась ошибка паники", info.Status, info.Error)
+	}
+}
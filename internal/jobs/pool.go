@@ -0,0 +1,107 @@
+package jobs
+
+import "sync"
+
+// Pool ограничивает параллельную обработку: общее количество воркеров
+// и количество одновременных задач внутри одной группы (например, одного домена iikoWeb)
+type Pool struct {
+	workers  int
+	perGroup int
+}
+
+// NewPool создает пул с указанным количеством воркеров и лимитом на группу
+func NewPool(workers, perGroup int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if perGroup < 1 {
+		perGroup = 1
+	}
+
+	return &Pool{
+		workers:  workers,
+		perGroup: perGroup,
+	}
+}
+
+// Run вызывает fn для каждого индекса от 0 до n-1 и ждет завершения всех вызовов.
+// group возвращает группу элемента; элементы одной группы обрабатываются не более чем perGroup одновременно.
+// Воркер берет только элемент группы со свободным слотом, поэтому большая группа
+// не занимает всех воркеров, пока элементы других групп ждут
+func (p *Pool) Run(n int, group func(i int) string, fn func(i int)) {
+	// Очереди элементов по группам в исходном порядке; groups - порядок групп по первому элементу
+	queues := make(map[string][]int)
+	var groups []string
+	for i := 0; i < n; i++ {
+		key := group(i)
+		if _, ok := queues[key]; !ok {
+			groups = append(groups, key)
+		}
+		queues[key] = append(queues[key], i)
+	}
+
+	var (
+		mu      sync.Mutex
+		ready   = sync.NewCond(&mu)
+		active  = make(map[string]int)
+		pending = n
+	)
+
+	// take ждет элемент группы со свободным слотом; false - элементы закончились
+	take := func() (int, string, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for pending > 0 {
+			for _, key := range groups {
+				if len(queues[key]) == 0 || active[key] >= p.perGroup {
+					continue
+				}
+				i := queues[key][0]
+				queues[key] = queues[key][1:]
+				active[key]++
+				pending--
+				return i, key, true
+			}
+			ready.Wait()
+		}
+		return 0, "", false
+	}
+
+	// release освобождает слот группы и будит воркеров, ждущих свободный слот
+	release := func(key string) {
+		mu.Lock()
+		active[key]--
+		mu.Unlock()
+		ready.Broadcast()
+	}
+
+	workers := p.workers
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i, key, ok := take()
+				if !ok {
+					return
+				}
+				p.runOne(key, i, fn, release)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runOne выполняет fn и освобождает слот группы
+func (p *Pool) runOne(key string, i int, fn func(i int), release func(key string)) {
+	defer release(key)
+
+	fn(i)
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolRunsEveryItemOnce(t *testing.T) {
+	const n = 50
+	var calls [n]int32
+
+	NewPool(4, 2).Run(n, func(i int) string {
+		return []string{"a", "b", "c"}[i%3]
+	}, func(i int) {
+		atomic.AddInt32(&calls[i], 1)
+	})
+
+	for i, count := range calls {
+		if count != 1 {
+			t.Errorf("элемент %d обработан %d раз", i, count)
+		}
+	}
+}
+
+func TestPoolLimitsConcurrency(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		total       int
+		maxTotal    int
+		perGroup    = map[string]int{}
+		maxPerGroup = map[string]int{}
+	)
+	groups := []string{"a", "a", "a", "a", "b", "b", "b", "c", "c", "d"}
+
+	NewPool(3, 2).Run(len(groups), func(i int) string {
+		return groups[i]
+	}, func(i int) {
+		key := groups[i]
+
+		mu.Lock()
+		total++
+		perGroup[key]++
+		if total > maxTotal {
+			maxTotal = total
+		}
+		if perGroup[key] > maxPerGroup[key] {
+			maxPerGroup[key] = perGroup[key]
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		total--
+		perGroup[key]--
+		mu.Unlock()
+	})
+
+	if maxTotal > 3 {
+		t.Errorf("одновременно выполнялось %d элементов, лимит 3", maxTotal)
+	}
+	for key, max := range maxPerGroup {
+		if max > 2 {
+			t.Errorf("группа %s: одновременно выполнялось %d элементов, лимит 2", key, max)
+		}
+	}
+}
+
+// Пока единственный слот большой группы занят, свободные воркеры должны брать элементы других групп
+func TestPoolDoesNotStallOnBusyGroup(t *testing.T) {
+	groups := []string{"big", "big", "big", "big", "other"}
+	blocked := make(chan struct{})
+	otherDone := make(chan struct{})
+
+	finished := make(chan struct{})
+	go func() {
+		NewPool(3, 1).Run(len(groups), func(i int) string {
+			return groups[i]
+		}, func(i int) {
+			if groups[i] == "other" {
+				close(otherDone)
+				return
+			}
+			<-blocked
+		})
+		close(finished)
+	}()
+
+	select {
+	case <-otherDone:
+	case <-time.After(time.Second):
+		t.Fatal("элемент другой группы не обработан, пока занята большая группа")
+	}
+
+	close(blocked)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился")
+	}
+}
@@ -0,0 +1,116 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout - формат дат истечения API логинов в iiko
+const DateLayout = "02.01.2006"
+
+// Extension описывает, как продлевать API ключ: на период, до фиксированной даты или сделать бессрочным
+type Extension struct {
+	Years  int
+	Months int
+	Days   int
+
+	TargetDate time.Time // если задана, ключ продлевается до этой даты
+	LongLived  bool      // ключ помечается бессрочным (isLongLived), дата не меняется
+
+	MaxDate time.Time // дата истечения не может быть позже
+}
+
+// Validate проверяет, что задан ровно один способ продления
+func (e Extension) Validate() error {
+	if e.Years < 0 || e.Months < 0 || e.Days < 0 {
+		return fmt.Errorf("период продления не может быть отрицательным")
+	}
+
+	modes := 0
+	if e.Years > 0 || e.Months > 0 || e.Days > 0 {
+		modes++
+	}
+	if !e.TargetDate.IsZero() {
+		modes++
+	}
+	if e.LongLived {
+		modes++
+	}
+
+	switch {
+	case modes == 0:
+		return fmt.Errorf("не задан период продления, целевая дата или long_lived")
+	case modes > 1:
+		return fmt.Errorf("период продления, целевая дата и long_lived нельзя задавать одновременно")
+	}
+
+	if !e.TargetDate.IsZero() {
+		if !e.TargetDate.After(today()) {
+			return fmt.Errorf("целевая дата %s должна быть в будущем", e.TargetDate.Format(DateLayout))
+		}
+		if !e.MaxDate.IsZero() && e.TargetDate.After(e.MaxDate) {
+			return fmt.Errorf("целевая дата %s позже максимальной %s",
+				e.TargetDate.Format(DateLayout), e.MaxDate.Format(DateLayout))
+		}
+	}
+
+	return nil
+}
+
+// Apply вычисляет новую дату истечения для текущей даты; не применяется в режиме LongLived
+func (e Extension) Apply(current time.Time) time.Time {
+	newDate := e.TargetDate
+	if newDate.IsZero() {
+		newDate = current.AddDate(e.Years, e.Months, e.Days)
+	}
+
+	if !e.MaxDate.IsZero() && newDate.After(e.MaxDate) {
+		newDate = e.MaxDate
+	}
+
+	return newDate
+}
+
+// String описывает способ продления для логов
+func (e Extension) String() string {
+	switch {
+	case e.LongLived:
+		return "бессрочно"
+	case !e.TargetDate.IsZero():
+		return "до " + e.TargetDate.Format(DateLayout)
+	}
+
+	var parts []string
+	if e.Years > 0 {
+		parts = append(parts, fmt.Sprintf("%d г.", e.Years))
+	}
+	if e.Months > 0 {
+		parts = append(parts, fmt.Sprintf("%d мес.", e.Months))
+	}
+	if e.Days > 0 {
+		parts = append(parts, fmt.Sprintf("%d дн.", e.Days))
+	}
+	return "на " + strings.Join(parts, " ")
+}
+
+// ParseDate разбирает дату в формате iiko (31.12.2027) или ISO (2027-12-31)
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range []string{DateLayout, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("некорректная дата %s, ожидается ДД.ММ.ГГГГ или ГГГГ-ММ-ДД", value)
+}
+
+// DaysUntil возвращает количество дней от сегодняшней даты до даты истечения; отрицательное - ключ уже истек
+func DaysUntil(expiration time.Time) int {
+	return int(expiration.Sub(today()).Hours() / 24)
+}
+
+// today возвращает текущую дату без времени в UTC, как даты iiko
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
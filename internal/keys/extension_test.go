@@ -0,0 +1,98 @@
+package keys
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtensionValidate(t *testing.T) {
+	maxDate := today().AddDate(10, 0, 0)
+
+	tests := []struct {
+		name      string
+		extension Extension
+		wantErr   bool
+	}{
+		{"период", Extension{Years: 2}, false},
+		{"период из нескольких частей", Extension{Years: 1, Months: 6, Days: 10}, false},
+		{"целевая дата", Extension{TargetDate: today().AddDate(1, 0, 0), MaxDate: maxDate}, false},
+		{"бессрочно", Extension{LongLived: true}, false},
+		{"ничего не задано", Extension{}, true},
+		{"отрицательный период", Extension{Years: -1}, true},
+		{"период и целевая дата", Extension{Years: 1, TargetDate: today().AddDate(1, 0, 0)}, true},
+		{"период и бессрочно", Extension{Days: 1, LongLived: true}, true},
+		{"целевая дата сегодня", Extension{TargetDate: today()}, true},
+		{"целевая дата в прошлом", Extension{TargetDate: today().AddDate(0, 0, -1)}, true},
+		{"целевая дата позже максимальной", Extension{TargetDate: maxDate.AddDate(0, 0, 1), MaxDate: maxDate}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.extension.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtensionApply(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	current := date(2025, 1, 31)
+
+	tests := []struct {
+		name      string
+		extension Extension
+		want      time.Time
+	}{
+		{"годы", Extension{Years: 2}, date(2027, 1, 31)},
+		{"месяцы и дни", Extension{Months: 1, Days: 1}, date(2025, 3, 4)},
+		{"ограничение максимальной датой", Extension{Years: 5, MaxDate: date(2026, 12, 31)}, date(2026, 12, 31)},
+		{"целевая дата", Extension{TargetDate: date(2030, 6, 1)}, date(2030, 6, 1)},
+		{"целевая дата не зависит от текущей", Extension{TargetDate: date(2024, 6, 1)}, date(2024, 6, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.extension.Apply(current); !got.Equal(tt.want) {
+				t.Errorf("Apply(%s) = %s, ожидалось %s", current.Format(DateLayout), got.Format(DateLayout), tt.want.Format(DateLayout))
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{"31.12.2027", "2027-12-31"} {
+		got, err := ParseDate(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %s, %v", value, got, err)
+		}
+	}
+
+	for _, value := range []string{"", "31/12/2027", "2027-13-01", "32.12.2027"} {
+		if _, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q): ожидалась ошибка", value)
+		}
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	tests := []struct {
+		expiration time.Time
+		want       int
+	}{
+		{today(), 0},
+		{today().AddDate(0, 0, 7), 7},
+		{today().AddDate(0, 0, -3), -3},
+	}
+
+	for _, tt := range tests {
+		if got := DaysUntil(tt.expiration); got != tt.want {
+			t.Errorf("DaysUntil(%s) = %d, ожидалось %d", tt.expiration.Format(DateLayout), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package keys
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy определяет, какие ключи пора продлевать и до какого горизонта.
+// Повторные запуски с одной политикой не трогают ключи, которые еще не пора продлевать
+type Policy struct {
+	ThresholdDays int // продлевать только ключи, истекающие в ближайшие N дней; 0 - продлевать всегда
+	HorizonDays   int // если задан, ключ продлевается до сегодня + N дней вместо периода по умолчанию
+}
+
+// Validate проверяет корректность политики
+func (p Policy) Validate() error {
+	if p.ThresholdDays < 0 || p.HorizonDays < 0 {
+		return fmt.Errorf("порог и горизонт продления не могут быть отрицательными")
+	}
+	// Иначе сразу после продления ключ снова попадает под порог и продлевается при каждом запуске
+	if p.HorizonDays > 0 && p.HorizonDays <= p.ThresholdDays {
+		return fmt.Errorf("горизонт продления (%d дн.) должен быть больше порога (%d дн.)", p.HorizonDays, p.ThresholdDays)
+	}
+	return nil
+}
+
+// Due проверяет, пора ли продлевать ключ с указанной датой истечения
+func (p Policy) Due(expiration time.Time) bool {
+	if p.ThresholdDays == 0 {
+		return true
+	}
+	return !expiration.After(today().AddDate(0, 0, p.ThresholdDays))
+}
+
+// Extension возвращает продление с учетом горизонта: при заданном HorizonDays ключ продлевается до фиксированной даты
+func (p Policy) Extension(extension Extension) Extension {
+	if p.HorizonDays == 0 || extension.LongLived {
+		return extension
+	}
+	return Extension{
+		TargetDate: today().AddDate(0, 0, p.HorizonDays),
+		MaxDate:    extension.MaxDate,
+	}
+}
+
+// String описывает политику для логов
+func (p Policy) String() string {
+	text := "всегда"
+	if p.ThresholdDays > 0 {
+		text = fmt.Sprintf("за %d дн. до истечения", p.ThresholdDays)
+	}
+	if p.HorizonDays > 0 {
+		text += fmt.Sprintf(", до горизонта %d дн.", p.HorizonDays)
+	}
+	return text
+}
+
+// WithOverride возвращает политику с переопределенными для ресторана значениями
+func (p Policy) WithOverride(thresholdDays, horizonDays *int) Policy {
+	if thresholdDays != nil {
+		p.ThresholdDays = *thresholdDays
+	}
+	if horizonDays != nil {
+		p.HorizonDays = *horizonDays
+	}
+	return p
+}
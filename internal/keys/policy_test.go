@@ -0,0 +1,88 @@
+package keys
+
+import "testing"
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"по умолчанию", Policy{}, false},
+		{"только порог", Policy{ThresholdDays: 30}, false},
+		{"только горизонт", Policy{HorizonDays: 365}, false},
+		{"горизонт больше порога", Policy{ThresholdDays: 30, HorizonDays: 365}, false},
+		{"горизонт равен порогу", Policy{ThresholdDays: 30, HorizonDays: 30}, true},
+		{"горизонт меньше порога", Policy{ThresholdDays: 30, HorizonDays: 7}, true},
+		{"отрицательный порог", Policy{ThresholdDays: -1}, true},
+		{"отрицательный горизонт", Policy{HorizonDays: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		days      int // дней до истечения ключа
+		want      bool
+	}{
+		{"без порога продлевается всегда", 0, 1000, true},
+		{"истекает раньше порога", 30, 10, true},
+		{"истекает ровно на пороге", 30, 30, true},
+		{"истекает позже порога", 30, 31, false},
+		{"уже истек", 30, -5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := Policy{ThresholdDays: tt.threshold}
+			if got := policy.Due(today().AddDate(0, 0, tt.days)); got != tt.want {
+				t.Errorf("Due() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyExtension(t *testing.T) {
+	maxDate := today().AddDate(50, 0, 0)
+	period := Extension{Years: 2, MaxDate: maxDate}
+
+	if got := (Policy{}).Extension(period); got != period {
+		t.Errorf("без горизонта продление не должно меняться: %+v", got)
+	}
+
+	longLived := Extension{LongLived: true}
+	if got := (Policy{HorizonDays: 365}).Extension(longLived); got != longLived {
+		t.Errorf("бессрочное продление не должно меняться: %+v", got)
+	}
+
+	got := Policy{HorizonDays: 365}.Extension(period)
+	want := Extension{TargetDate: today().AddDate(0, 0, 365), MaxDate: maxDate}
+	if got != want {
+		t.Errorf("Extension() = %+v, ожидалось %+v", got, want)
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("продление до горизонта должно быть корректным: %v", err)
+	}
+}
+
+func TestPolicyWithOverride(t *testing.T) {
+	threshold, horizon := 0, 90
+	base := Policy{ThresholdDays: 30, HorizonDays: 365}
+
+	if got := base.WithOverride(nil, nil); got != base {
+		t.Errorf("без переопределений политика не должна меняться: %+v", got)
+	}
+	if got := base.WithOverride(&threshold, &horizon); got != (Policy{ThresholdDays: 0, HorizonDays: 90}) {
+		t.Errorf("WithOverride() = %+v", got)
+	}
+}
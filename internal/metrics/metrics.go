@@ -0,0 +1,291 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Бакеты длительности в секундах
+var (
+	// DefaultBuckets - для HTTP запросов, как в клиентах Prometheus
+	DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// RunBuckets - для запусков операций, которые идут от секунд до MINION_RUN_TIMEOUT
+	RunBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+)
+
+// Default - реестр, который отдает GET /metrics
+var Default = NewRegistry()
+
+// Sample - одно значение метрики с метками в порядке объявления
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// metric - метрика, которая умеет записать себя в текстовом формате Prometheus
+type metric interface {
+	write(w *bytes.Buffer)
+}
+
+// Registry хранит метрики и отдает их в текстовом формате Prometheus
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry создает пустой реестр
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter регистрирует счетчик с метками labels
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	counter := &Counter{vec: newVec(name, help, labels)}
+	r.register(counter)
+	return counter
+}
+
+// NewHistogram регистрирует гистограмму с верхними границами бакетов buckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	histogram := &Histogram{vec: newVec(name, help, labels), buckets: buckets}
+	r.register(histogram)
+	return histogram
+}
+
+// NewGaugeFunc регистрирует gauge, значения которого считает collect при каждом чтении метрик
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) *GaugeFunc {
+	gauge := &GaugeFunc{vec: newVec(name, help, labels), collect: collect}
+	r.register(gauge)
+	return gauge
+}
+
+// register добавляет метрику в реестр
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo записывает все метрики в текстовом формате Prometheus
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.write(&buf)
+	}
+	return buf.WriteTo(w)
+}
+
+// vec - общая часть метрик: имя, описание и метки
+type vec struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels}
+}
+
+// key склеивает значения меток в ключ серии
+func (v vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("метрика %s: ожидается %d меток, передано %d", v.name, len(v.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// header пишет строки HELP и TYPE
+func (v vec) header(w *bytes.Buffer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, kind)
+}
+
+// line пишет одно значение серии; extra - дополнительная пара метки, например le
+func (v vec) line(w *bytes.Buffer, suffix string, values []string, extra []string, value float64) {
+	w.WriteString(v.name)
+	w.WriteString(suffix)
+
+	names := v.labels
+	if extra != nil {
+		names = append(append([]string(nil), names...), extra[0])
+		values = append(append([]string(nil), values...), extra[1])
+	}
+	if len(names) > 0 {
+		w.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, name, escapeLabel(values[i]))
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+// Counter - монотонно растущий счетчик
+type Counter struct {
+	vec
+	mu     sync.Mutex
+	series map[string]*Sample
+}
+
+// Inc увеличивает счетчик серии на 1
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add увеличивает счетчик серии на value
+func (c *Counter) Add(value float64, labels ...string) {
+	key := c.key(labels)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.series == nil {
+		c.series = map[string]*Sample{}
+	}
+	sample, ok := c.series[key]
+	if !ok {
+		sample = &Sample{Labels: append([]string(nil), labels...)}
+		c.series[key] = sample
+	}
+	sample.Value += value
+}
+
+func (c *Counter) write(w *bytes.Buffer) {
+	c.header(w, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sample := c.series[key]
+		c.line(w, "", sample.Labels, nil, sample.Value)
+	}
+}
+
+// Histogram распределяет наблюдения по бакетам
+type Histogram struct {
+	vec
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // по бакетам, без накопления
+	count  uint64
+	sum    float64
+}
+
+// Observe добавляет наблюдение value в серию
+func (h *Histogram) Observe(value float64, labels ...string) {
+	key := h.key(labels)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.series == nil {
+		h.series = map[string]*histogramSeries{}
+	}
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{labels: append([]string(nil), labels...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+
+	series.count++
+	series.sum += value
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+			break
+		}
+	}
+}
+
+func (h *Histogram) write(w *bytes.Buffer) {
+	h.header(w, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		series := h.series[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			h.line(w, "_bucket", series.labels, []string{"le", formatValue(bound)}, float64(cumulative))
+		}
+		h.line(w, "_bucket", series.labels, []string{"le", "+Inf"}, float64(series.count))
+		h.line(w, "_sum", series.labels, nil, series.sum)
+		h.line(w, "_count", series.labels, nil, float64(series.count))
+	}
+}
+
+// GaugeFunc - gauge, значения которого вычисляются при чтении
+type GaugeFunc struct {
+	vec
+	collect func() []Sample
+}
+
+func (g *GaugeFunc) write(w *bytes.Buffer) {
+	g.header(w, "gauge")
+
+	samples := g.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	for _, sample := range samples {
+		g.key(sample.Labels) // проверяет количество меток
+		g.line(w, "", sample.Labels, nil, sample.Value)
+	}
+}
+
+// escapeLabel экранирует значение метки по правилам текстового формата
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue форматирует число так, как его ожидает Prometheus
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
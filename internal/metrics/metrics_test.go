@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// exposition возвращает метрики реестра в текстовом формате
+func exposition(t *testing.T, registry *Registry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := registry.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() вернул ошибку: %v", err)
+	}
+	return buf.String()
+}
+
+func TestRegistryWriteTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *Registry)
+		want  string
+	}{
+		{
+			name: "счетчик без серий",
+			setup: func(r *Registry) {
+				r.NewCounter("jobs_total", "Количество задач", "status")
+			},
+			want: "# HELP jobs_total Количество задач\n" +
+				"# TYPE jobs_total counter\n",
+		},
+		{
+			name: "счетчик без меток",
+			setup: func(r *Registry) {
+				c := r.NewCounter("ticks_total", "Тики")
+				c.Inc()
+				c.Add(1.5)
+			},
+			want: "# HELP ticks_total Тики\n" +
+				"# TYPE ticks_total counter\n" +
+				"ticks_total 2.5\n",
+		},
+		{
+			name: "серии счетчика отсортированы",
+			setup: func(r *Registry) {
+				c := r.NewCounter("requests_total", "Запросы", "method", "status")
+				c.Inc("POST", "500")
+				c.Inc("GET", "200")
+				c.Inc("GET", "200")
+			},
+			want: "# HELP requests_total Запросы\n" +
+				"# TYPE requests_total counter\n" +
+				`requests_total{method="GET",status="200"} 2` + "\n" +
+				`requests_total{method="POST",status="500"} 1` + "\n",
+		},
+		{
+			name: "экранирование меток и описания",
+			setup: func(r *Registry) {
+				c := r.NewCounter("names_total", "Строка 1\nстрока 2 \\", "name")
+				c.Inc("Кафе \"У моря\"\\\n")
+			},
+			want: "# HELP names_total Строка 1\\nстрока 2 \\\\\n" +
+				"# TYPE names_total counter\n" +
+				`names_total{name="Кафе \"У моря\"\\\n"} 1` + "\n",
+		},
+		{
+			name: "гистограмма с накоплением бакетов",
+			setup: func(r *Registry) {
+				h := r.NewHistogram("duration_seconds", "Длительность", []float64{0.1, 1, 5}, "route")
+				h.Observe(0.05, "/api")
+				h.Observe(0.5, "/api")
+				h.Observe(0.7, "/api")
+				h.Observe(10, "/api")
+			},
+			want: "# HELP duration_seconds Длительность\n" +
+				"# TYPE duration_seconds histogram\n" +
+				`duration_seconds_bucket{route="/api",le="0.1"} 1` + "\n" +
+				`duration_seconds_bucket{route="/api",le="1"} 3` + "\n" +
+				`duration_seconds_bucket{route="/api",le="5"} 3` + "\n" +
+				`duration_seconds_bucket{route="/api",le="+Inf"} 4` + "\n" +
+				`duration_seconds_sum{route="/api"} 11.25` + "\n" +
+				`duration_seconds_count{route="/api"} 4` + "\n",
+		},
+		{
+			name: "gauge считается при чтении и сортируется",
+			setup: func(r *Registry) {
+				r.NewGaugeFunc("expiry_days", "Дни", []string{"id"}, func() []Sample {
+					return []Sample{
+						{Labels: []string{"b"}, Value: -3},
+						{Labels: []string{"a"}, Value: math.Inf(1)},
+					}
+				})
+			},
+			want: "# HELP expiry_days Дни\n" +
+				"# TYPE expiry_days gauge\n" +
+				`expiry_days{id="a"} +Inf` + "\n" +
+				`expiry_days{id="b"} -3` + "\n",
+		},
+		{
+			name: "метрики в порядке регистрации",
+			setup: func(r *Registry) {
+				r.NewCounter("b_total", "B").Inc()
+				r.NewCounter("a_total", "A").Inc()
+			},
+			want: "# HELP b_total B\n# TYPE b_total counter\nb_total 1\n" +
+				"# HELP a_total A\n# TYPE a_total counter\na_total 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			tt.setup(registry)
+
+			if got := exposition(t, registry); got != tt.want {
+				t.Errorf("WriteTo() =\n%s\nожидалось\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelCountPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(r *Registry)
+	}{
+		{"счетчик без меток", func(r *Registry) { r.NewCounter("c_total", "C", "status").Inc() }},
+		{"лишняя метка счетчика", func(r *Registry) { r.NewCounter("c_total", "C", "status").Inc("200", "GET") }},
+		{"гистограмма без меток", func(r *Registry) { r.NewHistogram("h", "H", DefaultBuckets, "route").Observe(1) }},
+		{"gauge с лишней меткой", func(r *Registry) {
+			r.NewGaugeFunc("g", "G", nil, func() []Sample { return []Sample{{Labels: []string{"x"}}} })
+			exposition(t, r)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("ожидалась паника из-за количества меток")
+				}
+			}()
+			tt.call(NewRegistry())
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// KeyAlert - предупреждение об истекающем API ключе; одно на логин и порог
+type KeyAlert struct {
+	ID             string    `json:"id" bson:"_id"`
+	RestaurantID   string    `json:"restaurant_id" bson:"restaurant_id"`
+	Restaurant     string    `json:"restaurant" bson:"restaurant"`
+	ApiLoginID     string    `json:"api_login_id" bson:"api_login_id"`
+	ApiLogin       string    `json:"api_login" bson:"api_login"`
+	ExpirationDate string    `json:"expiration_date" bson:"expiration_date"`
+	DaysRemaining  int       `json:"days_remaining" bson:"days_remaining"`
+	Threshold      int       `json:"threshold" bson:"threshold"` // порог в днях, который пересек ключ
+	RaisedAt       time.Time `json:"raised_at" bson:"raised_at"`
+	CheckedAt      time.Time `json:"checked_at" bson:"checked_at"` // последняя проверка, подтвердившая предупреждение
+}
+
+// KeyAlertID формирует идентификатор предупреждения для дедупликации по логину и порогу
+func KeyAlertID(restaurantID, apiLoginID string, threshold int) string {
+	return fmt.Sprintf("%s:%s:%d", restaurantID, apiLoginID, threshold)
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Источники изменяющих вызовов
+const (
+	AuditSourceAPI      = "api"
+	AuditSourceTelegram = "telegram"
+)
+
+// Итоги изменяющих вызовов
+const (
+	AuditOutcomeSuccess  = "success"  // вызов принят (2xx)
+	AuditOutcomeRejected = "rejected" // отклонен: параметры, права, конфликт (4xx)
+	AuditOutcomeError    = "error"    // ошибка сервера (5xx)
+)
+
+// AuditEntry - запись журнала аудита в коллекции minion_audit; записи только добавляются
+type AuditEntry struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Time     time.Time           `json:"time" bson:"time"`
+	Source   string              `json:"source" bson:"source"`
+	Identity string              `json:"identity" bson:"identity"` // api_key:имя, jwt:имя, telegram:пользователь@чат
+	Role     string              `json:"role,omitempty" bson:"role,omitempty"`
+	IP       string              `json:"ip,omitempty" bson:"ip,omitempty"`
+	Method   string              `json:"method" bson:"method"`
+	Endpoint string              `json:"endpoint" bson:"endpoint"` // маршрут (/api/jobs/:id/cancel) или команда Telegram
+	Path     string              `json:"path,omitempty" bson:"path,omitempty"`
+	Params   AuditParams         `json:"params" bson:"params"`
+	Selector *RestaurantSelector `json:"selector,omitempty" bson:"selector,omitempty"` // рестораны, к которым относится вызов
+	JobID    string              `json:"job_id,omitempty" bson:"job_id,omitempty"`
+	Status   int                 `json:"status" bson:"status"` // HTTP статус ответа
+	Outcome  string              `json:"outcome" bson:"outcome"`
+	Error    string              `json:"error,omitempty" bson:"error,omitempty"`
+
+	// Рестораны, которые выбирал селектор на момент вызова
+	Restaurants []AuditRestaurant `json:"restaurants,omitempty" bson:"restaurants,omitempty"`
+}
+
+// AuditRestaurant - ресторан, к которому относился вызов
+type AuditRestaurant struct {
+	ID   string `json:"id" bson:"id"`
+	Name string `json:"name" bson:"name"`
+}
+
+// AuditParams - параметры вызова
+type AuditParams struct {
+	Body  interface{}       `json:"body,omitempty" bson:"body,omitempty"` // JSON тело запроса или аргументы команды
+	Query map[string]string `json:"query,omitempty" bson:"query,omitempty"`
+	Path  map[string]string `json:"path,omitempty" bson:"path,omitempty"`
+}
+
+// AuditFilter - условия поиска записей аудита
+type AuditFilter struct {
+	Identity   string
+	Endpoint   string
+	Outcome    string
+	Restaurant string // id или название ресторана из селектора или выбранных ресторанов
+	From       time.Time
+	To         time.Time
+	Limit      int64
+}
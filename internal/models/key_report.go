@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// KeyReport - отчет о сроках действия API логинов по всем ресторанам
+type KeyReport struct {
+	GeneratedAt time.Time        `json:"generated_at" bson:"generated_at"`
+	Selector    string           `json:"selector" bson:"selector"`
+	Restaurants int              `json:"restaurants" bson:"restaurants"`
+	Keys        []KeyReportEntry `json:"keys" bson:"keys"`
+	Errors      []KeyReportError `json:"errors,omitempty" bson:"errors,omitempty"` // рестораны, по которым не удалось получить логины
+}
+
+// KeyReportEntry описывает один API логин ресторана
+type KeyReportEntry struct {
+	RestaurantID   string         `json:"restaurant_id" bson:"restaurant_id"`
+	Restaurant     string         `json:"restaurant" bson:"restaurant"`
+	ApiLoginID     string         `json:"api_login_id" bson:"api_login_id"`
+	ApiLogin       string         `json:"api_login" bson:"api_login"`
+	ExpirationDate string         `json:"expiration_date,omitempty" bson:"expiration_date,omitempty"`
+	DaysRemaining  *int           `json:"days_remaining,omitempty" bson:"days_remaining,omitempty"` // nil для бессрочных и логинов без даты
+	IsActive       bool           `json:"is_active" bson:"is_active"`
+	IsLongLived    bool           `json:"is_long_lived" bson:"is_long_lived"`
+	ExternalMenus  []ExternalMenu `json:"external_menus" bson:"external_menus"`
+	RestaurantMenu bool           `json:"restaurant_menu" bson:"restaurant_menu"` // к логину привязано внешнее меню ресторана
+}
+
+// KeyReportError описывает ресторан, по которому не удалось получить логины
+type KeyReportError struct {
+	RestaurantID string `json:"restaurant_id" bson:"restaurant_id"`
+	Restaurant   string `json:"restaurant" bson:"restaurant"`
+	Error        string `json:"error" bson:"error"`
+	ErrorCode    string `json:"error_code" bson:"error_code"`
+}
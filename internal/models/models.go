@@ -2,12 +2,22 @@ package models
 
 // Ресторан
 type Restaurant struct {
+	ID                 string `json:"id"`
 	Name               string `json:"name"`
 	BaseURL            string `json:"base_url"`
 	Login              string `json:"login"`
 	Password           string `json:"password"`
 	Enabled            bool   `json:"enabled"`
 	IikoExternalMenuId string `json:"iiko_external_menu_id"`
+
+	// Переопределение политики продления ключей для ресторана; nil - берется из конфигурации
+	KeyThresholdDays *int `json:"key_threshold_days,omitempty"`
+	KeyHorizonDays   *int `json:"key_horizon_days,omitempty"`
+
+	// Оповещения ресторана в WhatsApp
+	SendWhatsappNotification bool   `json:"send_whatsapp_notification"`
+	WhatsappChatID           string `json:"whatsapp_chat_id,omitempty"`
+	StorePhoneNumber         string `json:"store_phone_number,omitempty"`
 }
 
 // Запрос авторизации
@@ -0,0 +1,116 @@
+package models
+
+// Названия операций
+const (
+	OperationExtendKeys   = "extend-keys"
+	OperationRefreshMenus = "refresh-menus"
+	OperationKeyReport    = "key-report" // обновление отчета по ключам для GET /api/keys
+)
+
+// Статусы обработки ресторана
+const (
+	RestaurantStatusSuccess = "success"
+	RestaurantStatusPartial = "partial" // часть логинов или меню обработать не удалось
+	RestaurantStatusFailed  = "failed"
+)
+
+// Действия над API логином или меню
+const (
+	ActionExtended     = "extended"
+	ActionRefreshed    = "refreshed"
+	ActionSkipped      = "skipped"
+	ActionFailed       = "failed"
+	ActionWouldExtend  = "would_extend"  // dry run: логин был бы продлен
+	ActionWouldRefresh = "would_refresh" // dry run: меню было бы обновлено
+)
+
+// Причины пропуска API логина
+const (
+	SkipReasonInactive            = "inactive"
+	SkipReasonNoExpirationDate    = "no_expiration_date"
+	SkipReasonAlreadyAtMax        = "already_at_max"        // дата уже равна максимальной
+	SkipReasonAlreadyBeyondTarget = "already_beyond_target" // ключ уже действует дольше целевой даты
+	SkipReasonAlreadyLongLived    = "already_long_lived"    // ключ уже бессрочный
+	SkipReasonNotDue              = "not_due"               // до истечения больше порога политики продления
+)
+
+// Причины, по которым у ресторана нечего обрабатывать
+const (
+	MismatchMenuNotConfigured = "menu_not_configured" // у ресторана не задан external_menu_id
+	MismatchMenuNotFound      = "menu_not_found"      // меню с external_menu_id нет в iiko или оно не привязано ни к одному API логину
+)
+
+// OperationResult содержит результаты выполнения операции
+type OperationResult struct {
+	ProcessedRestaurants int                `json:"processed_restaurants" bson:"processed_restaurants"`
+	Successful           int                `json:"successful" bson:"successful"`
+	Partial              int                `json:"partial" bson:"partial"`
+	Failed               int                `json:"failed" bson:"failed"`
+	DryRun               bool               `json:"dry_run,omitempty" bson:"dry_run,omitempty"`
+	Selector             string             `json:"selector" bson:"selector"` // какие рестораны были выбраны
+	Duration             string             `json:"duration" bson:"duration"`
+	Details              []RestaurantResult `json:"details" bson:"details"`
+}
+
+// RestaurantResult содержит результат обработки одного ресторана
+type RestaurantResult struct {
+	ID      string `json:"id,omitempty" bson:"id,omitempty"` // Mongo _id ресторана
+	Name    string `json:"name" bson:"name"`
+	Success bool   `json:"success" bson:"success"`
+	Status  string `json:"status" bson:"status"`
+	Updated int    `json:"updated" bson:"updated"`
+	Message string `json:"message,omitempty" bson:"message,omitempty"`
+	Error   string `json:"error,omitempty" bson:"error,omitempty"`
+
+	// Машиночитаемый код ошибки (auth_failed, rate_limited, server_error, ...)
+	ErrorCode string `json:"error_code,omitempty" bson:"error_code,omitempty"`
+
+	// Количество HTTP запросов к iiko, включая повторы, и количество повторов
+	Attempts int `json:"attempts" bson:"attempts"`
+	Retries  int `json:"retries" bson:"retries"`
+
+	// Почему у ресторана не нашлось логинов или меню для обработки
+	Mismatch string `json:"mismatch,omitempty" bson:"mismatch,omitempty"`
+
+	// Результаты по каждому подходящему API логину (extend-keys) или меню (refresh-menus)
+	ApiLogins []ApiLoginResult `json:"api_logins,omitempty" bson:"api_logins,omitempty"`
+	Menus     []MenuResult     `json:"menus,omitempty" bson:"menus,omitempty"`
+}
+
+// ApiLoginResult содержит результат обработки одного API логина
+type ApiLoginResult struct {
+	ID            string `json:"id" bson:"id"`
+	Name          string `json:"name" bson:"name"`
+	OldExpiration string `json:"old_expiration,omitempty" bson:"old_expiration,omitempty"`
+	NewExpiration string `json:"new_expiration,omitempty" bson:"new_expiration,omitempty"`
+	LongLived     bool   `json:"long_lived,omitempty" bson:"long_lived,omitempty"`
+	Action        string `json:"action" bson:"action"`
+	SkipReason    string `json:"skip_reason,omitempty" bson:"skip_reason,omitempty"`
+	Error         string `json:"error,omitempty" bson:"error,omitempty"`
+	ErrorCode     string `json:"error_code,omitempty" bson:"error_code,omitempty"`
+}
+
+// MenuResult содержит результат обновления одного внешнего меню
+type MenuResult struct {
+	ID        int    `json:"id" bson:"id"`
+	Name      string `json:"name" bson:"name"`
+	Action    string `json:"action" bson:"action"`
+	Error     string `json:"error,omitempty" bson:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty" bson:"error_code,omitempty"`
+}
+
+// FailedItems возвращает количество API логинов и меню, которые не удалось обработать
+func (r *RestaurantResult) FailedItems() int {
+	failed := 0
+	for _, apiLogin := range r.ApiLogins {
+		if apiLogin.Action == ActionFailed {
+			failed++
+		}
+	}
+	for _, menu := range r.Menus {
+		if menu.Action == ActionFailed {
+			failed++
+		}
+	}
+	return failed
+}
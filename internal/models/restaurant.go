@@ -18,6 +18,7 @@ type RestaurantMongo struct {
 	WhatsappErrorStoplistChatID string             `bson:"whatsapp_error_stoplist_chat_id"`
 	IikoCloud                   IikoCloudConfig    `bson:"iiko_cloud"`
 	Settings                    RestaurantSettings `bson:"settings"`
+	Minion                      MinionSettings     `bson:"minion"`
 	SendToPos                   bool               `bson:"send_to_pos"`
 	IsDeleted                   bool               `bson:"is_deleted"`
 	IntegrationDate             time.Time          `bson:"integration_date"`
@@ -46,6 +47,31 @@ type RestaurantSettings struct {
 	LanguageCode  string `bson:"language_code"`
 }
 
+// MinionSettings содержит настройки minion для конкретного ресторана и итоги последних запусков
+type MinionSettings struct {
+	KeyThresholdDays *int `bson:"key_threshold_days,omitempty"` // продлевать ключи за N дней до истечения
+	KeyHorizonDays   *int `bson:"key_horizon_days,omitempty"`   // продлевать ключи до сегодня + N дней
+
+	// Заполняются minion после каждого запуска (кроме dry run)
+	LastRunID          string     `bson:"last_run_id,omitempty"`
+	LastKeyExtensionAt *time.Time `bson:"last_key_extension_at,omitempty"` // когда ключи последний раз продлевались
+	KeyExpiresAt       *time.Time `bson:"key_expires_at,omitempty"`        // ближайшая дата истечения API логинов ресторана
+	LastMenuRefreshAt  *time.Time `bson:"last_menu_refresh_at,omitempty"`  // когда меню последний раз обновлялось
+	LastError          string     `bson:"last_error,omitempty"`            // ошибка последнего запуска, сбрасывается при успехе
+	LastErrorAt        *time.Time `bson:"last_error_at,omitempty"`
+}
+
+// MinionStatusUpdate - итог запуска для записи в документ ресторана; nil поля не меняются
+type MinionStatusUpdate struct {
+	RestaurantID       string
+	RunID              string
+	LastKeyExtensionAt *time.Time
+	KeyExpiresAt       *time.Time
+	LastMenuRefreshAt  *time.Time
+	LastError          string // пустая строка сбрасывает last_error
+	At                 time.Time
+}
+
 // ToMinion конвертирует RestaurantMongo в Restaurant для minion
 func (r *RestaurantMongo) ToMinion() *Restaurant {
 	// Проверяем, что это iiko ресторан и у него есть необходимые данные
@@ -58,12 +84,19 @@ func (r *RestaurantMongo) ToMinion() *Restaurant {
 
 	// Создаем Restaurant для minion
 	return &Restaurant{
+		ID:                 r.ID.Hex(),
 		Name:               r.Name,
 		BaseURL:            baseURL,
 		Login:              r.IikoCloud.Login,
 		Password:           r.IikoCloud.Password,
 		Enabled:            !r.IsDeleted && !r.Settings.IsDeleted,
 		IikoExternalMenuId: r.IikoCloud.ExternalMenuID,
+		KeyThresholdDays:   r.Minion.KeyThresholdDays,
+		KeyHorizonDays:     r.Minion.KeyHorizonDays,
+
+		SendWhatsappNotification: r.SendWhatsappNotification,
+		WhatsappChatID:           r.WhatsappErrorStoplistChatID,
+		StorePhoneNumber:         r.StorePhoneNumber,
 	}
 }
 
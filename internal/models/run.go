@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Кто запустил операцию
+const (
+	TriggerManual   = "manual"   // запрос к API
+	TriggerSchedule = "schedule" // встроенный планировщик
+	TriggerTelegram = "telegram" // команда Telegram бота
+)
+
+// Trigger описывает источник запуска операции
+type Trigger struct {
+	Type     string `json:"type" bson:"type"`
+	CallerIP string `json:"caller_ip,omitempty" bson:"caller_ip,omitempty"`
+	Identity string `json:"identity,omitempty" bson:"identity,omitempty"` // клиент API (api_key:имя) или пользователь Telegram
+}
+
+// String описывает источник для логов
+func (t Trigger) String() string {
+	text := t.Type
+	if t.Identity != "" {
+		text += " " + t.Identity
+	}
+	if t.CallerIP != "" {
+		text += " " + t.CallerIP
+	}
+	return text
+}
+
+// RunParams содержит параметры запуска операции
+type RunParams struct {
+	DryRun    bool               `json:"dry_run" bson:"dry_run"`
+	Selector  RestaurantSelector `json:"selector" bson:"selector"`
+	Extension string             `json:"extension,omitempty" bson:"extension,omitempty"` // только extend-keys
+}
+
+// Run - запись истории запуска операции в коллекции minion_runs; ID совпадает с ID задачи
+type Run struct {
+	ID         string           `json:"id" bson:"_id"`
+	Operation  string           `json:"operation" bson:"operation"`
+	Trigger    Trigger          `json:"trigger" bson:"trigger"`
+	Params     RunParams        `json:"params" bson:"params"`
+	Status     string           `json:"status" bson:"status"` // статус задачи: running, completed, failed, cancelled
+	Error      string           `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt  time.Time        `json:"started_at" bson:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	Result     *OperationResult `json:"result,omitempty" bson:"result,omitempty"`
+}
+
+// RunFilter - условия поиска запусков
+type RunFilter struct {
+	Operation  string
+	Status     string
+	Restaurant string // id или название ресторана
+	From       time.Time
+	To         time.Time
+	Limit      int64
+}
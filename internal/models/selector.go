@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RestaurantSelector отбирает рестораны для операции.
+// Внутри одного поля значения объединяются через ИЛИ, разные поля - через И.
+// Пустой селектор означает все активные iiko рестораны
+type RestaurantSelector struct {
+	IDs      []string `json:"ids,omitempty" bson:"ids,omitempty"`             // Mongo _id ресторанов
+	Names    []string `json:"names,omitempty" bson:"names,omitempty"`         // названия, без учета регистра
+	Cities   []string `json:"cities,omitempty" bson:"cities,omitempty"`       // города, без учета регистра
+	PosTypes []string `json:"pos_types,omitempty" bson:"pos_types,omitempty"` // pos_type; обрабатываются только iiko рестораны
+	Domains  []string `json:"domains,omitempty" bson:"domains,omitempty"`     // iiko_web_domain, можно с https://
+}
+
+// IsEmpty проверяет, что селектор не ограничивает рестораны
+func (s RestaurantSelector) IsEmpty() bool {
+	return len(s.IDs) == 0 && len(s.Names) == 0 && len(s.Cities) == 0 &&
+		len(s.PosTypes) == 0 && len(s.Domains) == 0
+}
+
+// Validate проверяет значения селектора
+func (s RestaurantSelector) Validate() error {
+	for _, id := range s.IDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return fmt.Errorf("некорректный id ресторана: %s", id)
+		}
+	}
+
+	fields := map[string][]string{
+		"names":     s.Names,
+		"cities":    s.Cities,
+		"pos_types": s.PosTypes,
+		"domains":   s.Domains,
+	}
+	for field, values := range fields {
+		for _, value := range values {
+			if strings.TrimSpace(value) == "" {
+				return fmt.Errorf("пустое значение в %s", field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// String описывает селектор для логов
+func (s RestaurantSelector) String() string {
+	if s.IsEmpty() {
+		return "все рестораны"
+	}
+
+	var parts []string
+	add := func(field string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%s", field, strings.Join(values, ",")))
+		}
+	}
+	add("ids", s.IDs)
+	add("names", s.Names)
+	add("cities", s.Cities)
+	add("pos_types", s.PosTypes)
+	add("domains", s.Domains)
+
+	return strings.Join(parts, " ")
+}
+
+// NormalizeDomain приводит домен iiko к виду, в котором он хранится в iiko_web_domain
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	return strings.TrimSuffix(domain, "/")
+}
@@ -0,0 +1,79 @@
+package models
+
+import "testing"
+
+func TestRestaurantSelectorValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector RestaurantSelector
+		wantErr  bool
+	}{
+		{"пустой селектор", RestaurantSelector{}, false},
+		{"корректный id", RestaurantSelector{IDs: []string{"64b7f0c2a1b2c3d4e5f60718"}}, false},
+		{"некорректный id", RestaurantSelector{IDs: []string{"123"}}, true},
+		{"названия и города", RestaurantSelector{Names: []string{"Кафе"}, Cities: []string{"Москва"}}, false},
+		{"пустое название", RestaurantSelector{Names: []string{"Кафе", "  "}}, true},
+		{"пустой город", RestaurantSelector{Cities: []string{""}}, true},
+		{"пустой pos_type", RestaurantSelector{PosTypes: []string{" "}}, true},
+		{"пустой домен", RestaurantSelector{Domains: []string{""}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.selector.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, ожидалась ошибка: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestaurantSelectorString(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector RestaurantSelector
+		want     string
+	}{
+		{"пустой селектор", RestaurantSelector{}, "все рестораны"},
+		{"одно поле", RestaurantSelector{Cities: []string{"Москва", "Казань"}}, "cities=Москва,Казань"},
+		{
+			"все поля по порядку",
+			RestaurantSelector{
+				Domains:  []string{"cafe.iikoweb.ru"},
+				PosTypes: []string{"iiko"},
+				Names:    []string{"Кафе"},
+				IDs:      []string{"64b7f0c2a1b2c3d4e5f60718"},
+			},
+			"ids=64b7f0c2a1b2c3d4e5f60718 names=Кафе pos_types=iiko domains=cafe.iikoweb.ru",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.String(); got != tt.want {
+				t.Errorf("String() = %q, ожидалось %q", got, tt.want)
+			}
+			if empty := tt.selector.IsEmpty(); empty != (tt.want == "все рестораны") {
+				t.Errorf("IsEmpty() = %t", empty)
+			}
+		})
+	}
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"cafe.iikoweb.ru", "cafe.iikoweb.ru"},
+		{" Cafe.IikoWeb.ru ", "cafe.iikoweb.ru"},
+		{"https://cafe.iikoweb.ru/", "cafe.iikoweb.ru"},
+		{"http://cafe.iikoweb.ru", "cafe.iikoweb.ru"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeDomain(tt.domain); got != tt.want {
+			t.Errorf("NormalizeDomain(%q) = %q, ожидалось %q", tt.domain, got, tt.want)
+		}
+	}
+}
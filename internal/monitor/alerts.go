@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"minion/internal/models"
+)
+
+// ParseThresholds разбирает пороги в днях ("30,7,1") и сортирует их по убыванию
+func ParseThresholds(value string) ([]int, error) {
+	var thresholds []int
+	seen := map[int]bool{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		threshold, err := strconv.Atoi(part)
+		if err != nil || threshold < 0 {
+			return nil, fmt.Errorf("некорректный порог %q, ожидается неотрицательное число дней", part)
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	if len(thresholds) == 0 {
+		return nil, fmt.Errorf("не задан ни один порог")
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(thresholds)))
+	return thresholds, nil
+}
+
+// Evaluate возвращает предупреждения по всем порогам, которые пересекли ключи отчета.
+// Учитываются только активные ключи с датой, привязанные к внешнему меню ресторана, -
+// те, от которых зависит интеграция и которые продлевает extend-keys
+func Evaluate(report *models.KeyReport, thresholds []int, now time.Time) []models.KeyAlert {
+	var alerts []models.KeyAlert
+	for _, entry := range report.Keys {
+		if !entry.IsActive || !entry.RestaurantMenu || entry.DaysRemaining == nil {
+			continue
+		}
+
+		for _, threshold := range thresholds {
+			if *entry.DaysRemaining > threshold {
+				continue
+			}
+			alerts = append(alerts, models.KeyAlert{
+				ID:             models.KeyAlertID(entry.RestaurantID, entry.ApiLoginID, threshold),
+				RestaurantID:   entry.RestaurantID,
+				Restaurant:     entry.Restaurant,
+				ApiLoginID:     entry.ApiLoginID,
+				ApiLogin:       entry.ApiLogin,
+				ExpirationDate: entry.ExpirationDate,
+				DaysRemaining:  *entry.DaysRemaining,
+				Threshold:      threshold,
+				RaisedAt:       now,
+				CheckedAt:      now,
+			})
+		}
+	}
+	return alerts
+}
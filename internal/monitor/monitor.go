@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// CheckFunc выполняет одну проверку сроков действия ключей
+type CheckFunc func(ctx context.Context) error
+
+// Status - состояние мониторинга для API
+type Status struct {
+	Interval    string     `json:"interval"`
+	LastCheckAt *time.Time `json:"last_check_at,omitempty"`
+	NextCheckAt *time.Time `json:"next_check_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// Monitor периодически запускает проверку ключей в фоне
+type Monitor struct {
+	interval time.Duration
+	check    CheckFunc
+
+	mu          sync.RWMutex
+	lastCheckAt time.Time
+	nextCheckAt time.Time
+	lastError   string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor создает мониторинг, проверяющий ключи каждые interval
+func NewMonitor(interval time.Duration, check CheckFunc) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Monitor{
+		interval: interval,
+		check:    check,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start запускает первую проверку сразу, а следующие - каждые interval
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// Stop останавливает мониторинг и прерывает текущую проверку
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Status возвращает время последней и следующей проверки
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{
+		Interval:  m.interval.String(),
+		LastError: m.lastError,
+	}
+	if !m.lastCheckAt.IsZero() {
+		lastCheckAt := m.lastCheckAt
+		status.LastCheckAt = &lastCheckAt
+	}
+	if !m.nextCheckAt.IsZero() {
+		nextCheckAt := m.nextCheckAt
+		status.NextCheckAt = &nextCheckAt
+	}
+	return status
+}
+
+// loop выполняет проверки, пока мониторинг не остановлен
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.run()
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run выполняет одну проверку и запоминает ее итог
+func (m *Monitor) run() {
+	startedAt := time.Now()
+	err := m.check(m.ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastCheckAt = startedAt
+	m.nextCheckAt = startedAt.Add(m.interval)
+	m.lastError = ""
+	if err != nil {
+		log.Printf("❌ Мониторинг ключей: %v", err)
+		m.lastError = err.Error()
+	}
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"minion/internal/jobs"
+	"minion/internal/models"
+)
+
+// Типы событий
+const (
+	EventRunCompleted     = "run_completed"     // запуск операции завершен
+	EventRestaurantFailed = "restaurant_failed" // ресторан обработан с ошибками
+	EventKeyExpiring      = "key_expiring"      // ключ пересек порог предупреждения
+)
+
+// Events - все типы событий
+var Events = []string{EventRunCompleted, EventRestaurantFailed, EventKeyExpiring}
+
+// ParseEvents разбирает список событий через запятую; пустой список - все события
+func ParseEvents(value string) ([]string, error) {
+	var events []string
+	for _, part := range strings.Split(value, ",") {
+		event := strings.TrimSpace(part)
+		if event == "" {
+			continue
+		}
+		if !isKnown(event) {
+			return nil, fmt.Errorf("неизвестное событие %s, ожидается одно из: %s", event, strings.Join(Events, ", "))
+		}
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return Events, nil
+	}
+	return events, nil
+}
+
+// isKnown проверяет, что тип события существует
+func isKnown(event string) bool {
+	for _, known := range Events {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event - событие для оповещения
+type Event struct {
+	Type       string                   `json:"type"`
+	Time       time.Time                `json:"time"`
+	Text       string                   `json:"text"` // готовое описание для людей
+	Run        *RunSummary              `json:"run,omitempty"`
+	Restaurant *models.RestaurantResult `json:"restaurant,omitempty"` // restaurant_failed
+	Alert      *models.KeyAlert         `json:"alert,omitempty"`      // key_expiring
+}
+
+// RestaurantID возвращает ресторан, к которому относится событие; пусто для событий запуска
+func (e Event) RestaurantID() string {
+	switch {
+	case e.Restaurant != nil:
+		return e.Restaurant.ID
+	case e.Alert != nil:
+		return e.Alert.RestaurantID
+	}
+	return ""
+}
+
+// RunSummary - итог запуска без результатов по ресторанам
+type RunSummary struct {
+	ID         string         `json:"id"`
+	Operation  string         `json:"operation"`
+	Trigger    models.Trigger `json:"trigger"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DryRun     bool           `json:"dry_run,omitempty"`
+	Selector   string         `json:"selector,omitempty"`
+	Processed  int            `json:"processed_restaurants"`
+	Successful int            `json:"successful"`
+	Partial    int            `json:"partial"`
+	Failed     int            `json:"failed"`
+	Duration   string         `json:"duration,omitempty"`
+}
+
+// NewRunSummary формирует итог запуска
+func NewRunSummary(run *models.Run) *RunSummary {
+	summary := &RunSummary{
+		ID:        run.ID,
+		Operation: run.Operation,
+		Trigger:   run.Trigger,
+		Status:    run.Status,
+		Error:     run.Error,
+		DryRun:    run.Params.DryRun,
+		Selector:  run.Params.Selector.String(),
+	}
+	if run.Result != nil {
+		summary.Processed = run.Result.ProcessedRestaurants
+		summary.Successful = run.Result.Successful
+		summary.Partial = run.Result.Partial
+		summary.Failed = run.Result.Failed
+		summary.Duration = run.Result.Duration
+	}
+	return summary
+}
+
+// RunCompleted формирует событие о завершении запуска
+func RunCompleted(run *models.Run) Event {
+	summary := NewRunSummary(run)
+
+	text := fmt.Sprintf("%s %s: %s", statusEmoji(summary), summary.Operation, summary.Status)
+	if summary.DryRun {
+		text += " (dry run)"
+	}
+	if run.Result != nil {
+		text += fmt.Sprintf(", ресторанов %d: успешно %d, частично %d, с ошибками %d, за %s",
+			summary.Processed, summary.Successful, summary.Partial, summary.Failed, summary.Duration)
+	}
+	if summary.Error != "" {
+		text += ": " + summary.Error
+	}
+
+	return Event{Type: EventRunCompleted, Time: time.Now(), Text: text, Run: summary}
+}
+
+// RestaurantFailed формирует событие об ошибке обработки ресторана
+func RestaurantFailed(run *models.Run, restaurant models.RestaurantResult) Event {
+	text := fmt.Sprintf("❌ %s: ресторан %s - %s", run.Operation, restaurant.Name, restaurant.Status)
+	if restaurant.Error != "" {
+		text += ": " + restaurant.Error
+	} else if failed := restaurant.FailedItems(); failed > 0 {
+		text += fmt.Sprintf(": не обработано %d", failed)
+	}
+
+	return Event{
+		Type:       EventRestaurantFailed,
+		Time:       time.Now(),
+		Text:       text,
+		Run:        NewRunSummary(run),
+		Restaurant: &restaurant,
+	}
+}
+
+// KeyExpiring формирует событие об истекающем ключе
+func KeyExpiring(alert models.KeyAlert) Event {
+	text := fmt.Sprintf("🚨 Ключ %s ресторана %s истекает %s: осталось %d дн.",
+		alert.ApiLogin, alert.Restaurant, alert.ExpirationDate, alert.DaysRemaining)
+
+	return Event{Type: EventKeyExpiring, Time: time.Now(), Text: text, Alert: &alert}
+}
+
+// statusEmoji подбирает эмодзи по итогу запуска
+func statusEmoji(summary *RunSummary) string {
+	switch {
+	case summary.Status == jobs.StatusCancelled:
+		return "⏹️"
+	case summary.Status == jobs.StatusFailed || summary.Failed > 0:
+		return "❌"
+	case summary.Partial > 0:
+		return "⚠️"
+	}
+	return "✅"
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// retryBaseDelay - задержка перед первым повтором доставки, дальше удваивается
+const retryBaseDelay = time.Second
+
+// httpClient - общий HTTP клиент каналов оповещений
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON отправляет тело запроса методом POST, повторяя его при сетевых ошибках, 429 и 5xx
+func postJSON(ctx context.Context, url string, headers map[string]string, body []byte, maxAttempts int) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		retryable, err := post(ctx, url, headers, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= maxAttempts {
+			return fmt.Errorf("попытка %d: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("попытка %d: %w", attempt, err)
+		}
+		delay *= 2
+	}
+}
+
+// post выполняет одну попытку; retryable - стоит ли повторять запрос
+func post(ctx context.Context, url string, headers map[string]string, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("ошибка создания запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "minion")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("неожиданный статус %d", resp.StatusCode)
+}
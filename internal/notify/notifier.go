@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Notifier доставляет события в один канал оповещений (webhook, мессенджер)
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// route связывает канал с событиями, которые в него отправляются
+type route struct {
+	notifier Notifier
+	events   map[string]bool
+}
+
+// Router рассылает события по каналам в фоне, не задерживая операции
+type Router struct {
+	timeout time.Duration
+	routes  []route
+	wg      sync.WaitGroup
+}
+
+// NewRouter создает маршрутизатор; timeout ограничивает доставку одного события в один канал, включая повторы
+func NewRouter(timeout time.Duration) *Router {
+	return &Router{timeout: timeout}
+}
+
+// Add подключает канал для перечисленных событий
+func (r *Router) Add(notifier Notifier, events []string) {
+	set := make(map[string]bool, len(events))
+	for _, event := range events {
+		set[event] = true
+	}
+	r.routes = append(r.routes, route{notifier: notifier, events: set})
+}
+
+// Dispatch отправляет событие во все каналы, подписанные на его тип. Ошибки доставки только логируются
+func (r *Router) Dispatch(event Event) {
+	if r == nil {
+		return
+	}
+
+	for _, rt := range r.routes {
+		if !rt.events[event.Type] {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(notifier Notifier) {
+			defer r.wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+			defer cancel()
+
+			if err := notifier.Notify(ctx, event); err != nil {
+				log.Printf("⚠️ Оповещение %s не доставлено в %s: %v", event.Type, notifier.Name(), err)
+			}
+		}(rt.notifier)
+	}
+}
+
+// Close ждет доставки уже отправленных событий
+func (r *Router) Close() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Заголовки запроса webhook
+const (
+	HeaderEvent     = "X-Minion-Event"
+	HeaderDelivery  = "X-Minion-Delivery"  // одинаковый во всех повторах, для дедупликации на стороне получателя
+	HeaderSignature = "X-Minion-Signature" // sha256=<hex HMAC-SHA256 тела запроса>
+)
+
+// Webhook отправляет события JSON-ом методом POST на заданный URL
+type Webhook struct {
+	name        string
+	url         string
+	secret      string
+	maxAttempts int
+}
+
+// NewWebhook создает канал webhook; без secret запросы не подписываются
+func NewWebhook(name, url, secret string, maxAttempts int) *Webhook {
+	return &Webhook{
+		name:        name,
+		url:         url,
+		secret:      secret,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Name возвращает имя канала для логов
+func (w *Webhook) Name() string {
+	return "webhook " + w.name
+}
+
+// Notify отправляет событие, повторяя запрос при сетевых ошибках, 429 и 5xx
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события: %v", err)
+	}
+
+	headers := map[string]string{
+		HeaderEvent:    event.Type,
+		HeaderDelivery: newDeliveryID(),
+	}
+	if w.secret != "" {
+		headers[HeaderSignature] = "sha256=" + Sign(w.secret, body)
+	}
+
+	return postJSON(ctx, w.url, headers, body, w.maxAttempts)
+}
+
+// Sign вычисляет hex HMAC-SHA256 тела запроса; получатель сверяет его с заголовком X-Minion-Signature
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newDeliveryID генерирует идентификатор доставки
+func newDeliveryID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// contactsTTL - как долго кешируются контакты ресторанов, чтобы пачка ошибок одного запуска не читала базу на каждое событие
+const contactsTTL = 5 * time.Minute
+
+// Contact - настройки WhatsApp оповещений ресторана
+type Contact struct {
+	Restaurant string
+	Enabled    bool   // send_whatsapp_notification
+	ChatID     string // whatsapp_error_stoplist_chat_id
+	Phone      string // store_phone_number, если чат не задан
+}
+
+// ContactsFunc загружает контакты ресторанов по их id
+type ContactsFunc func(ctx context.Context) (map[string]Contact, error)
+
+// whatsappMessage - тело запроса к шлюзу WhatsApp
+type whatsappMessage struct {
+	ChatID string `json:"chat_id,omitempty"`
+	Phone  string `json:"phone,omitempty"`
+	Text   string `json:"text"`
+}
+
+// WhatsApp отправляет события ресторана в его WhatsApp чат через HTTP шлюз.
+// События без ресторана и рестораны без согласия на оповещения пропускаются
+type WhatsApp struct {
+	gatewayURL  string
+	token       string
+	maxAttempts int
+	limiter     *chatLimiter
+	load        ContactsFunc
+
+	mu         sync.Mutex
+	contacts   map[string]Contact
+	contactsAt time.Time
+}
+
+// NewWhatsApp создает канал WhatsApp; в каждый чат уходит не больше limit сообщений за window
+func NewWhatsApp(gatewayURL, token string, maxAttempts, limit int, window time.Duration, load ContactsFunc) *WhatsApp {
+	return &WhatsApp{
+		gatewayURL:  gatewayURL,
+		token:       token,
+		maxAttempts: maxAttempts,
+		limiter:     newChatLimiter(limit, window),
+		load:        load,
+	}
+}
+
+// Name возвращает имя канала для логов
+func (w *WhatsApp) Name() string {
+	return "whatsapp"
+}
+
+// Notify отправляет событие в чат ресторана
+func (w *WhatsApp) Notify(ctx context.Context, event Event) error {
+	restaurantID := event.RestaurantID()
+	if restaurantID == "" {
+		return nil
+	}
+
+	contact, err := w.contact(ctx, restaurantID)
+	if err != nil {
+		return err
+	}
+	if !contact.Enabled || (contact.ChatID == "" && contact.Phone == "") {
+		return nil
+	}
+
+	message := whatsappMessage{ChatID: contact.ChatID, Text: event.Text}
+	if contact.ChatID == "" {
+		message.Phone = contact.Phone
+	}
+
+	destination := message.ChatID + message.Phone
+	if !w.limiter.Allow(destination, time.Now()) {
+		log.Printf("🔇 WhatsApp: лимит сообщений в чат ресторана %s исчерпан, событие %s пропущено", contact.Restaurant, event.Type)
+		return nil
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации сообщения: %v", err)
+	}
+
+	headers := map[string]string{}
+	if w.token != "" {
+		headers["Authorization"] = "Bearer " + w.token
+	}
+
+	return postJSON(ctx, w.gatewayURL, headers, body, w.maxAttempts)
+}
+
+// contact возвращает контакт ресторана из кеша, обновляя кеш раз в contactsTTL
+func (w *WhatsApp) contact(ctx context.Context, restaurantID string) (Contact, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.contacts == nil || time.Since(w.contactsAt) > contactsTTL {
+		contacts, err := w.load(ctx)
+		if err != nil {
+			return Contact{}, fmt.Errorf("ошибка загрузки контактов ресторанов: %w", err)
+		}
+		w.contacts = contacts
+		w.contactsAt = time.Now()
+	}
+
+	return w.contacts[restaurantID], nil
+}
+
+// chatLimiter ограничивает количество сообщений в чат за скользящее окно
+type chatLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+func newChatLimiter(limit int, window time.Duration) *chatLimiter {
+	return &chatLimiter{limit: limit, window: window, sent: map[string][]time.Time{}}
+}
+
+// Allow учитывает сообщение в чат chat, если лимит окна еще не исчерпан
+func (l *chatLimiter) Allow(chat string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.sent[chat][:0]
+	for _, at := range l.sent[chat] {
+		if now.Sub(at) < l.window {
+			recent = append(recent, at)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.sent[chat] = recent
+		return false
+	}
+	l.sent[chat] = append(recent, now)
+	return true
+}
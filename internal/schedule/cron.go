@@ -0,0 +1,174 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron - разобранное cron выражение из пяти полей: минута, час, день месяца, месяц, день недели
+type Cron struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+
+	// Если ограничены и день месяца, и день недели, достаточно совпадения одного из них (как в cron)
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Сокращения для частых расписаний
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// maxSearch - как далеко вперед ищем следующий запуск
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// ParseCron разбирает cron выражение: "0 4 * * *", "*/15 9-18 * * 1-5", "@daily"
+func ParseCron(expression string) (*Cron, error) {
+	spec := strings.TrimSpace(expression)
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron выражение %q должно содержать 5 полей: минута час день месяц день_недели", expression)
+	}
+
+	cron := &Cron{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	if err := parseField(fields[0], 0, 59, cron.minutes[:]); err != nil {
+		return nil, fmt.Errorf("минуты: %w", err)
+	}
+	if err := parseField(fields[1], 0, 23, cron.hours[:]); err != nil {
+		return nil, fmt.Errorf("часы: %w", err)
+	}
+	if err := parseField(fields[2], 1, 31, cron.days[:]); err != nil {
+		return nil, fmt.Errorf("день месяца: %w", err)
+	}
+	if err := parseField(fields[3], 1, 12, cron.months[:]); err != nil {
+		return nil, fmt.Errorf("месяц: %w", err)
+	}
+
+	// День недели: 0-7, где и 0, и 7 - воскресенье
+	var weekdays [8]bool
+	if err := parseField(fields[4], 0, 7, weekdays[:]); err != nil {
+		return nil, fmt.Errorf("день недели: %w", err)
+	}
+	copy(cron.weekdays[:], weekdays[:7])
+	cron.weekdays[0] = cron.weekdays[0] || weekdays[7]
+
+	return cron, nil
+}
+
+// Next возвращает первое время срабатывания строго после after в часовом поясе after.
+// Нулевое время означает, что выражение не срабатывает (например, 31 февраля)
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		switch {
+		case !c.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayMatches проверяет день месяца и день недели
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.days[t.Day()]
+	weekday := c.weekdays[t.Weekday()]
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// parseField разбирает одно поле: "*", "5", "1-5", "*/15", "10-40/10", "1,15,30"
+func parseField(field string, min, max int, values []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			parsed, err := strconv.Atoi(part[i+1:])
+			if err != nil || parsed <= 0 {
+				return fmt.Errorf("некорректный шаг в %q", part)
+			}
+			step = parsed
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], min, max); err != nil {
+				return err
+			}
+			if high, err = parseValue(bounds[1], min, max); err != nil {
+				return err
+			}
+			if low > high {
+				return fmt.Errorf("некорректный диапазон %q", rangePart)
+			}
+		default:
+			value, err := parseValue(rangePart, min, max)
+			if err != nil {
+				return err
+			}
+			low = value
+			// "5/10" означает с 5 до конца с шагом 10, просто "5" - только 5
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+
+	return nil
+}
+
+// parseValue разбирает число и проверяет границы
+func parseValue(text string, min, max int) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное значение %q", text)
+	}
+	if value < min || value > max {
+		return 0, fmt.Errorf("значение %d вне диапазона %d-%d", value, min, max)
+	}
+	return value, nil
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"пустое выражение", ""},
+		{"мало полей", "0 4 * *"},
+		{"много полей", "0 4 * * * *"},
+		{"неизвестный макрос", "@yearly"},
+		{"минута вне диапазона", "60 * * * *"},
+		{"час вне диапазона", "0 24 * * *"},
+		{"нулевой день месяца", "0 0 0 * *"},
+		{"месяц вне диапазона", "0 0 1 13 *"},
+		{"день недели вне диапазона", "0 0 * * 8"},
+		{"не число", "a * * * *"},
+		{"обратный диапазон", "0 18-9 * * *"},
+		{"нулевой шаг", "*/0 * * * *"},
+		{"отрицательный шаг", "*/-5 * * * *"},
+		{"пустой элемент списка", "1,,2 * * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expression); err == nil {
+				t.Errorf("ParseCron(%q): ожидалась ошибка", tt.expression)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-15 - понедельник
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatalf("некорректное время %q: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		after      string
+		want       string
+	}{
+		{"каждую минуту", "* * * * *", "2024-01-15 10:00", "2024-01-15 10:01"},
+		{"строго после текущей минуты", "0 4 * * *", "2024-01-15 04:00", "2024-01-16 04:00"},
+		{"секунды отбрасываются", "30 10 * * *", "2024-01-15 10:29", "2024-01-15 10:30"},
+		{"шаг по минутам", "*/15 * * * *", "2024-01-15 10:16", "2024-01-15 10:30"},
+		{"шаг с началом", "5/20 * * * *", "2024-01-15 10:26", "2024-01-15 10:45"},
+		{"шаг в диапазоне", "10-40/10 * * * *", "2024-01-15 10:40", "2024-01-15 11:10"},
+		{"диапазон часов", "0 9-18 * * *", "2024-01-15 18:30", "2024-01-16 09:00"},
+		{"список минут", "1,15,30 * * * *", "2024-01-15 10:15", "2024-01-15 10:30"},
+		{"будни", "0 9 * * 1-5", "2024-01-19 10:00", "2024-01-22 09:00"},
+		{"воскресенье как 0", "0 0 * * 0", "2024-01-15 00:00", "2024-01-21 00:00"},
+		{"воскресенье как 7", "0 0 * * 7", "2024-01-15 00:00", "2024-01-21 00:00"},
+		{"день месяца или день недели", "0 0 20 * 1", "2024-01-15 00:00", "2024-01-20 00:00"},
+		{"день недели раньше дня месяца", "0 0 25 * 3", "2024-01-15 00:00", "2024-01-17 00:00"},
+		{"переход месяца", "0 0 1 * *", "2024-01-31 23:59", "2024-02-01 00:00"},
+		{"переход года", "0 0 1 1 *", "2024-06-01 00:00", "2025-01-01 00:00"},
+		{"31 число пропускает короткие месяцы", "0 0 31 * *", "2024-01-31 12:00", "2024-03-31 00:00"},
+		{"29 февраля", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"макрос daily", "@daily", "2024-01-15 10:00", "2024-01-16 00:00"},
+		{"макрос hourly", "@hourly", "2024-01-15 10:00", "2024-01-15 11:00"},
+		{"макрос weekly", "@weekly", "2024-01-15 10:00", "2024-01-21 00:00"},
+		{"макрос monthly", "@monthly", "2024-01-15 10:00", "2024-02-01 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expression, err)
+			}
+
+			got := cron.Next(at(tt.after))
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) для %q = %s, ожидалось %s", tt.after, tt.expression, got.Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	cron, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+
+	if next := cron.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("31 февраля не должно срабатывать, получено %s", next)
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	cron, err := ParseCron("0 4 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+
+	next := cron.Next(time.Date(2024, 1, 15, 5, 0, 0, 0, loc))
+	if want := time.Date(2024, 1, 16, 4, 0, 0, 0, loc); !next.Equal(want) || next.Location() != loc {
+		t.Errorf("Next = %s, ожидалось %s", next, want)
+	}
+}
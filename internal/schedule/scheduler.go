@@ -0,0 +1,313 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"minion/internal/jobs"
+)
+
+// TriggerFunc запускает операцию так же, как ручной запрос, и возвращает созданную задачу
+type TriggerFunc func() (*jobs.Job, error)
+
+// PausedFunc возвращает состояние паузы расписания, общее для всех реплик
+type PausedFunc func(ctx context.Context, name string) (bool, error)
+
+// LastRun описывает последнее срабатывание расписания
+type LastRun struct {
+	FiredAt time.Time `json:"fired_at"`
+	JobID   string    `json:"job_id,omitempty"`
+	Status  string    `json:"status"` // статус задачи или skipped/failed, если задача не создана
+	Error   string    `json:"error,omitempty"`
+}
+
+// Статусы срабатывания без задачи
+const (
+	FireSkipped = "skipped" // предыдущий запуск еще выполняется
+	FireFailed  = "failed"  // задачу не удалось создать
+)
+
+// ErrSkipped возвращается TriggerFunc, если операцию сейчас запускать не нужно (например, она уже выполняется)
+var ErrSkipped = errors.New("срабатывание пропущено")
+
+// Info - снимок состояния расписания для API
+type Info struct {
+	Name       string     `json:"name"`
+	Expression string     `json:"expression"`
+	Timezone   string     `json:"timezone"`
+	Paused     bool       `json:"paused"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
+	LastRun    *LastRun   `json:"last_run,omitempty"`
+}
+
+// entry - одно расписание операции
+type entry struct {
+	name       string
+	expression string
+	cron       *Cron
+	trigger    TriggerFunc
+
+	mu      sync.RWMutex
+	paused  bool
+	next    time.Time
+	lastRun *LastRun
+	lastJob *jobs.Job
+}
+
+// Scheduler запускает операции по cron расписаниям внутри процесса
+type Scheduler struct {
+	location   *time.Location
+	entries    []*entry
+	pausedFunc PausedFunc
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler создает планировщик, cron выражения которого считаются в часовом поясе location
+func NewScheduler(location *time.Location) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		location: location,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Add добавляет расписание операции; вызывается до Start
+func (s *Scheduler) Add(name, expression string, trigger TriggerFunc) error {
+	cron, err := ParseCron(expression)
+	if err != nil {
+		return fmt.Errorf("расписание %s: %w", name, err)
+	}
+
+	s.entries = append(s.entries, &entry{
+		name:       name,
+		expression: expression,
+		cron:       cron,
+		trigger:    trigger,
+	})
+	return nil
+}
+
+// SetPausedFunc задает общее хранилище пауз: перед каждым срабатыванием расписание
+// проверяет его, поэтому пауза через одну реплику действует на все. Без него пауза
+// действует только на этой реплике. Вызывается до Start
+func (s *Scheduler) SetPausedFunc(pausedFunc PausedFunc) {
+	s.pausedFunc = pausedFunc
+}
+
+// Refresh перечитывает состояние пауз из общего хранилища, чтобы List показывал паузы других реплик
+func (s *Scheduler) Refresh(ctx context.Context) {
+	for _, e := range s.entries {
+		s.refreshPaused(ctx, e)
+	}
+}
+
+// Start запускает все расписания в фоне
+func (s *Scheduler) Start() {
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop останавливает расписания; уже созданные задачи продолжают выполняться в менеджере задач
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// List возвращает состояние всех расписаний
+func (s *Scheduler) List() []Info {
+	infos := make([]Info, 0, len(s.entries))
+	for _, e := range s.entries {
+		infos = append(infos, s.info(e))
+	}
+	return infos
+}
+
+// Get возвращает состояние расписания по имени
+func (s *Scheduler) Get(name string) (Info, bool) {
+	e := s.find(name)
+	if e == nil {
+		return Info{}, false
+	}
+	return s.info(e), true
+}
+
+// Pause приостанавливает расписание; false, если расписание не найдено
+func (s *Scheduler) Pause(name string) bool {
+	return s.setPaused(name, true)
+}
+
+// Resume возобновляет расписание; false, если расписание не найдено
+func (s *Scheduler) Resume(name string) bool {
+	return s.setPaused(name, false)
+}
+
+// setPaused меняет состояние паузы на этой реплике
+func (s *Scheduler) setPaused(name string, paused bool) bool {
+	e := s.find(name)
+	if e == nil {
+		return false
+	}
+
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	return true
+}
+
+// refreshPaused обновляет паузу расписания из общего хранилища и возвращает ее.
+// Если хранилище недоступно, действует последнее известное состояние
+func (s *Scheduler) refreshPaused(ctx context.Context, e *entry) bool {
+	if s.pausedFunc != nil {
+		paused, err := s.pausedFunc(ctx, e.name)
+		if err != nil {
+			log.Printf("⚠️ Расписание %s: не удалось проверить паузу: %v", e.name, err)
+		} else {
+			e.mu.Lock()
+			e.paused = paused
+			e.mu.Unlock()
+		}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.paused
+}
+
+// find ищет расписание по имени
+func (s *Scheduler) find(name string) *entry {
+	for _, e := range s.entries {
+		if e.name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// loop ждет следующего срабатывания расписания, пока планировщик не остановлен.
+// Таймер идет и на паузе: пауза проверяется при срабатывании, потому что ее могли снять на другой реплике
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.cron.Next(time.Now().In(s.location))
+		e.mu.Lock()
+		e.next = next
+		e.mu.Unlock()
+
+		// Без следующего срабатывания ждем только остановки
+		var (
+			timer *time.Timer
+			fire  <-chan time.Time
+		)
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			stopTimer(timer)
+			return
+		case <-fire:
+			s.fire(e)
+		}
+	}
+}
+
+// fire запускает операцию, если расписание не на паузе и предыдущий запуск по нему уже завершился
+func (s *Scheduler) fire(e *entry) {
+	lastRun := &LastRun{FiredAt: time.Now()}
+
+	if s.refreshPaused(s.ctx, e) {
+		log.Printf("⏸️ Расписание %s на паузе, пропускаем", e.name)
+		return
+	}
+
+	e.mu.RLock()
+	lastJob := e.lastJob
+	e.mu.RUnlock()
+
+	if lastJob != nil && !finished(lastJob.Info().Status) {
+		log.Printf("⏭️  Расписание %s: предыдущая задача %s еще выполняется, пропускаем", e.name, lastJob.ID())
+		lastRun.Status = FireSkipped
+		lastRun.JobID = lastJob.ID()
+		e.mu.Lock()
+		e.lastRun = lastRun
+		e.mu.Unlock()
+		return
+	}
+
+	log.Printf("⏰ Расписание %s (%s): запуск операции", e.name, e.expression)
+	job, err := e.trigger()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastRun = lastRun
+	if errors.Is(err, ErrSkipped) {
+		log.Printf("⏭️  Расписание %s: %v", e.name, err)
+		lastRun.Status = FireSkipped
+		lastRun.Error = err.Error()
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Расписание %s: не удалось запустить операцию: %v", e.name, err)
+		lastRun.Status = FireFailed
+		lastRun.Error = err.Error()
+		return
+	}
+
+	lastRun.JobID = job.ID()
+	e.lastJob = job
+}
+
+// info формирует снимок состояния расписания; статус последнего запуска берется из задачи
+func (s *Scheduler) info(e *entry) Info {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	info := Info{
+		Name:       e.name,
+		Expression: e.expression,
+		Timezone:   s.location.String(),
+		Paused:     e.paused,
+	}
+	if !e.next.IsZero() && !e.paused {
+		next := e.next
+		info.NextRun = &next
+	}
+	if e.lastRun != nil {
+		lastRun := *e.lastRun
+		if lastRun.Status == "" && e.lastJob != nil {
+			jobInfo := e.lastJob.Info()
+			lastRun.Status = jobInfo.Status
+			lastRun.Error = jobInfo.Error
+		}
+		info.LastRun = &lastRun
+	}
+
+	return info
+}
+
+// stopTimer останавливает таймер, если он был создан
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// finished проверяет, что задача в конечном статусе
+func finished(status string) bool {
+	return status == jobs.StatusCompleted || status == jobs.StatusFailed || status == jobs.StatusCancelled
+}
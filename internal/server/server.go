@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"minion/internal/auth"
 	"minion/internal/handlers"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,7 +16,12 @@ import (
 )
 
 // StartServer запускает HTTP сервер
-func StartServer(port string) error {
+func StartServer(port, allowOrigins string) error {
+	// Загружаем API ключи до приема запросов
+	if err := handlers.StartAuth(); err != nil {
+		return err
+	}
+
 	// Создаем Fiber приложение
 	app := fiber.New(fiber.Config{
 		AppName: "🍌 Minion API v2.1.0",
@@ -37,27 +43,62 @@ func StartServer(port string) error {
 
 	// Middleware
 	app.Use(recover.New())
+	app.Use(handlers.Metrics)
 	app.Use(logger.New(logger.Config{
-		Format: "🍌 ${time} | ${status} | ${latency} | ${ip} | ${method} ${path}\n",
+		Format: "🍌 ${time} | ${status} | ${latency} | ${ip} | ${identity} | ${method} ${path}\n",
+		CustomTags: map[string]logger.LogFunc{
+			"identity": func(output logger.Buffer, c *fiber.Ctx, data *logger.Data, extraParam string) (int, error) {
+				return output.WriteString(handlers.RequestIdentity(c))
+			},
+		},
 	}))
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins: allowOrigins,
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
 		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
 	}))
 
+	// Метрики Prometheus, доступны с ролью viewer
+	app.Get("/metrics", handlers.Authenticate, handlers.RequireRole(auth.RoleViewer), handlers.GetMetrics)
+
 	// API Routes
 	api := app.Group("/api")
 
-	// Health check
+	// Health check, доступен без аутентификации
 	api.Get("/health", handlers.HealthCheck)
 
-	// Configuration
-	api.Get("/config", handlers.GetConfig)
-
-	// Main operations
-	api.Post("/extend-keys", handlers.ExtendKeys)
-	api.Post("/refresh-menus", handlers.RefreshMenus)
+	// Изменяющие вызовы пишутся в журнал аудита до аутентификации и проверки роли,
+	// чтобы в нем остались и отклоненные попытки, в том числе без ключа
+	api.Use(handlers.Audit)
+
+	// Все остальные /api маршруты требуют API ключ
+	api.Use(handlers.Authenticate)
+
+	// Роли проверяются на каждом маршруте: группа с пустым префиксом
+	// повесила бы свою проверку и на все маршруты, объявленные после нее
+	viewer := handlers.RequireRole(auth.RoleViewer)
+	operator := handlers.RequireRole(auth.RoleOperator)
+	admin := handlers.RequireRole(auth.RoleAdmin)
+
+	// viewer: конфигурация, отчеты, задачи, история и расписания
+	api.Get("/config", viewer, handlers.GetConfig)
+	api.Get("/keys", viewer, handlers.GetKeys)
+	api.Get("/alerts", viewer, handlers.GetAlerts)
+	api.Get("/jobs", viewer, handlers.GetJobs)
+	api.Get("/jobs/:id", viewer, handlers.GetJob)
+	api.Get("/runs", viewer, handlers.GetRuns)
+	api.Get("/runs/:id", viewer, handlers.GetRun)
+	api.Get("/schedules", viewer, handlers.GetSchedules)
+
+	// operator: обновление меню и отмена задач
+	api.Post("/refresh-menus", operator, handlers.RefreshMenus)
+	api.Post("/jobs/:id/cancel", operator, handlers.CancelJob)
+
+	// admin: продление ключей, управление расписаниями и журнал аудита
+	api.Post("/extend-keys", admin, handlers.ExtendKeys)
+	api.Post("/schedules/:name/pause", admin, handlers.PauseSchedule)
+	api.Post("/schedules/:name/resume", admin, handlers.ResumeSchedule)
+	api.Get("/audit", admin, handlers.GetAudit)
 
 	// Root endpoint
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -69,6 +110,18 @@ func StartServer(port string) error {
 				"GET  /api/config",
 				"POST /api/extend-keys",
 				"POST /api/refresh-menus",
+				"GET  /api/keys",
+				"GET  /api/alerts",
+				"GET  /api/jobs",
+				"GET  /api/jobs/:id",
+				"POST /api/jobs/:id/cancel",
+				"GET  /api/runs",
+				"GET  /api/runs/:id",
+				"GET  /api/schedules",
+				"POST /api/schedules/:name/pause",
+				"POST /api/schedules/:name/resume",
+				"GET  /api/audit",
+				"GET  /metrics",
 			},
 		})
 	})
@@ -80,6 +133,7 @@ func StartServer(port string) error {
 		<-sigint
 
 		log.Println("🛑 Получен сигнал остановки, завершаем сервер...")
+		handlers.Shutdown()
 		if err := app.Shutdown(); err != nil {
 			log.Printf("❌ Ошибка остановки сервера: %v", err)
 		}
@@ -91,6 +145,42 @@ func StartServer(port string) error {
 	log.Println("   GET  /api/health")
 	log.Println("   POST /api/extend-keys")
 	log.Println("   POST /api/refresh-menus")
+	log.Println("   GET  /api/keys")
+	log.Println("   GET  /api/alerts")
+	log.Println("   GET  /api/jobs")
+	log.Println("   GET  /api/jobs/:id")
+	log.Println("   POST /api/jobs/:id/cancel")
+	log.Println("   GET  /api/runs")
+	log.Println("   GET  /api/runs/:id")
+	log.Println("   GET  /api/schedules")
+	log.Println("   POST /api/schedules/:name/pause")
+	log.Println("   POST /api/schedules/:name/resume")
+	log.Println("   GET  /api/audit")
+	log.Println("   GET  /metrics")
+
+	// Общее подключение к MongoDB для блокировок, истории, предупреждений и аудита
+	handlers.StartStorage()
+
+	// Журнал аудита пишется в фоне, до приема изменяющих вызовов
+	handlers.StartAudit()
+
+	// Подключаем каналы оповещений до запуска операций
+	if err := handlers.StartNotifier(); err != nil {
+		return err
+	}
+	if err := handlers.StartTelegram(); err != nil {
+		return err
+	}
+
+	// Запускаем встроенный планировщик
+	if err := handlers.StartScheduler(); err != nil {
+		return err
+	}
+
+	// Запускаем мониторинг сроков действия ключей
+	if err := handlers.StartMonitor(); err != nil {
+		return err
+	}
 
 	return app.Listen(":" + port)
 }
@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"minion/internal/notify"
+)
+
+// Параметры long polling
+const (
+	pollTimeout    = 30 * time.Second
+	pollRetryDelay = 5 * time.Second
+	commandTimeout = 5 * time.Minute
+)
+
+// Command - команда оператора
+type Command struct {
+	Name   string // без слеша и имени бота: refresh
+	Args   string
+	ChatID int64
+	User   string
+}
+
+// CommandFunc выполняет команду и возвращает ответ для чата
+type CommandFunc func(ctx context.Context, command Command) string
+
+// Bot принимает команды из разрешенных чатов и публикует в них оповещения
+type Bot struct {
+	client   *Client
+	chats    []int64
+	allowed  map[int64]bool
+	commands map[string]CommandFunc
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBot создает бота; команды и оповещения доступны только чатам из chats
+func NewBot(client *Client, chats []int64) *Bot {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	allowed := make(map[int64]bool, len(chats))
+	for _, chat := range chats {
+		allowed[chat] = true
+	}
+
+	return &Bot{
+		client:   client,
+		chats:    chats,
+		allowed:  allowed,
+		commands: map[string]CommandFunc{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle регистрирует команду /name
+func (b *Bot) Handle(name string, fn CommandFunc) {
+	b.commands[name] = fn
+}
+
+// Start запускает long polling в фоне
+func (b *Bot) Start() {
+	b.wg.Add(1)
+	go b.poll()
+}
+
+// Stop останавливает polling и ждет завершения выполняющихся команд
+func (b *Bot) Stop() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+// Name возвращает имя канала для логов
+func (b *Bot) Name() string {
+	return "telegram"
+}
+
+// Notify публикует событие во все разрешенные чаты
+func (b *Bot) Notify(ctx context.Context, event notify.Event) error {
+	var errs []error
+	for _, chat := range b.chats {
+		if err := b.client.SendMessage(ctx, chat, event.Text); err != nil {
+			errs = append(errs, fmt.Errorf("чат %d: %w", chat, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// poll получает обновления, пока бот не остановлен
+func (b *Bot) poll() {
+	defer b.wg.Done()
+
+	offset, ok := b.skipPending()
+	for ok && b.ctx.Err() == nil {
+		updates, err := b.client.GetUpdates(b.ctx, offset, pollTimeout)
+		if err != nil {
+			b.retryAfterError(err)
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				b.handleMessage(*update.Message)
+			}
+		}
+	}
+}
+
+// skipPending пропускает обновления, накопившиеся, пока бот не работал: старые /refresh и /extend
+// не должны запускать операции после перезапуска. offset -1 возвращает только последнее обновление,
+// а offset за ним подтверждает все предыдущие. false - бот остановлен
+func (b *Bot) skipPending() (int64, bool) {
+	for b.ctx.Err() == nil {
+		updates, err := b.client.GetUpdates(b.ctx, -1, 0)
+		if err != nil {
+			b.retryAfterError(err)
+			continue
+		}
+
+		if len(updates) == 0 {
+			return 0, true
+		}
+		last := updates[len(updates)-1].UpdateID
+		log.Printf("⏭️  Telegram: пропущены команды, отправленные до запуска бота")
+		return last + 1, true
+	}
+	return 0, false
+}
+
+// retryAfterError логирует ошибку Bot API и ждет перед повтором, если бот не остановлен
+func (b *Bot) retryAfterError(err error) {
+	if b.ctx.Err() != nil {
+		return
+	}
+	log.Printf("⚠️ Telegram: %v, повтор через %s", err, pollRetryDelay)
+	select {
+	case <-time.After(pollRetryDelay):
+	case <-b.ctx.Done():
+	}
+}
+
+// handleMessage проверяет доступ и выполняет команду в фоне, чтобы не задерживать polling
+func (b *Bot) handleMessage(message Message) {
+	command, ok := parseCommand(message)
+	if !ok {
+		return
+	}
+
+	if !b.allowed[message.Chat.ID] {
+		log.Printf("⛔ Telegram: команда /%s из чата %d (%s) отклонена, чата нет в TELEGRAM_CHAT_IDS", command.Name, command.ChatID, command.User)
+		b.reply(command.ChatID, fmt.Sprintf("⛔ Нет доступа. ID этого чата: %d", command.ChatID))
+		return
+	}
+
+	fn, ok := b.commands[command.Name]
+	if !ok {
+		b.reply(command.ChatID, fmt.Sprintf("Неизвестная команда /%s, список команд - /help", command.Name))
+		return
+	}
+
+	log.Printf("🤖 Telegram: %s от %s (чат %d)", strings.TrimSpace("/"+command.Name+" "+command.Args), command.User, command.ChatID)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ctx, cancel := context.WithTimeout(b.ctx, commandTimeout)
+		defer cancel()
+
+		b.reply(command.ChatID, fn(ctx, command))
+	}()
+}
+
+// reply отправляет ответ на команду
+func (b *Bot) reply(chatID int64, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := b.client.SendMessage(ctx, chatID, text); err != nil {
+		log.Printf("⚠️ Telegram: не удалось ответить в чат %d: %v", chatID, err)
+	}
+}
+
+// parseCommand разбирает сообщение вида "/refresh@minion_bot Del Papa"
+func parseCommand(message Message) (Command, bool) {
+	text := strings.TrimSpace(message.Text)
+	if !strings.HasPrefix(text, "/") {
+		return Command{}, false
+	}
+
+	name, args, _ := strings.Cut(text[1:], " ")
+	name, _, _ = strings.Cut(name, "@")
+
+	command := Command{
+		Name:   strings.ToLower(name),
+		Args:   strings.TrimSpace(args),
+		ChatID: message.Chat.ID,
+	}
+	if message.From != nil {
+		command.User = message.From.Username
+		if command.User == "" {
+			command.User = fmt.Sprintf("%d", message.From.ID)
+		}
+	}
+
+	return command, command.Name != ""
+}
+
+// ParseChatIDs разбирает список ID чатов через запятую
+func ParseChatIDs(value string) ([]int64, error) {
+	var chats []int64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		chat, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный ID чата: %s", part)
+		}
+		chats = append(chats, chat)
+	}
+	return chats, nil
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBotAPI - локальный Bot API: pending отдается только на offset -1, fresh - на offset после него
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	offsets []int64
+	pending []Update
+	fresh   []Update
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Offset int64 `json:"offset"`
+	}
+	json.NewDecoder(r.Body).Decode(&params)
+
+	var result interface{} = true
+	if r.URL.Path == "/bottoken/getUpdates" {
+		f.mu.Lock()
+		f.offsets = append(f.offsets, params.Offset)
+		updates := []Update{}
+		switch {
+		case params.Offset == -1 && len(f.pending) > 0:
+			updates = f.pending[len(f.pending)-1:]
+		case params.Offset >= 0 && len(f.fresh) > 0 && params.Offset <= f.fresh[0].UpdateID:
+			updates = f.fresh
+		}
+		f.mu.Unlock()
+
+		if len(updates) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		result = updates
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func command(id int64, text string) Update {
+	return Update{UpdateID: id, Message: &Message{Text: text, Chat: Chat{ID: 1}}}
+}
+
+func TestBotSkipsPendingUpdates(t *testing.T) {
+	tests := []struct {
+		name        string
+		pending     []Update
+		fresh       []Update
+		wantOffset  int64
+		wantHandled string
+	}{
+		{
+			name:        "старые команды пропускаются",
+			pending:     []Update{command(5, "/refresh Кафе"), command(6, "/extend Кафе")},
+			fresh:       []Update{command(7, "/status")},
+			wantOffset:  7,
+			wantHandled: "status",
+		},
+		{
+			name:        "очередь пуста",
+			fresh:       []Update{command(1, "/status")},
+			wantOffset:  0,
+			wantHandled: "status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeBotAPI{pending: tt.pending, fresh: tt.fresh}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			bot := NewBot(NewClient(server.URL, "token"), []int64{1})
+			handled := make(chan string, 10)
+			for _, name := range []string{"refresh", "extend", "status"} {
+				bot.Handle(name, func(ctx context.Context, command Command) string {
+					handled <- command.Name
+					return "ok"
+				})
+			}
+
+			bot.Start()
+			defer bot.Stop()
+
+			select {
+			case name := <-handled:
+				if name != tt.wantHandled {
+					t.Fatalf("выполнена команда /%s, ожидалась /%s", name, tt.wantHandled)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("команда не выполнена")
+			}
+
+			api.mu.Lock()
+			offsets := append([]int64(nil), api.offsets...)
+			api.mu.Unlock()
+			if len(offsets) < 2 || offsets[0] != -1 || offsets[1] != tt.wantOffset {
+				t.Errorf("offset запросов %v, ожидалось [-1 %d ...]", offsets, tt.wantOffset)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxMessageLength - максимальная длина сообщения Telegram
+const maxMessageLength = 4096
+
+// Update - входящее обновление Bot API; нас интересуют только сообщения
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// Message - сообщение в чате
+type Message struct {
+	Text string `json:"text"`
+	Chat Chat   `json:"chat"`
+	From *User  `json:"from"`
+}
+
+// Chat - чат, в который пришло сообщение
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// User - автор сообщения
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// apiResponse - общий ответ Bot API
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// Client - клиент Telegram Bot API
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewClient создает клиент Bot API; baseURL можно заменить на локальный сервер для тестов
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: pollTimeout + 15*time.Second},
+	}
+}
+
+// GetUpdates ждет новые обновления начиная с offset до timeout (long polling)
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage отправляет текстовое сообщение в чат; слишком длинный текст обрезается
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	if runes := []rune(text); len(runes) > maxMessageLength {
+		text = string(runes[:maxMessageLength-1]) + "…"
+	}
+
+	return c.call(ctx, "sendMessage", map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}, nil)
+}
+
+// call вызывает метод Bot API и разбирает result в out
+func (c *Client) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации запроса %s: %v", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса %s: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Ошибка содержит URL с токеном бота
+		return fmt.Errorf("ошибка запроса %s: %v", method, strings.ReplaceAll(err.Error(), c.token, "***"))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения ответа %s: %v", method, err)
+	}
+
+	var response apiResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("некорректный ответ %s (статус %d): %v", method, resp.StatusCode, err)
+	}
+	if !response.OK {
+		return fmt.Errorf("%s: %s (статус %d)", method, response.Description, resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(response.Result, out); err != nil {
+			return fmt.Errorf("некорректный результат %s: %v", method, err)
+		}
+	}
+	return nil
+}